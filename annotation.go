@@ -0,0 +1,106 @@
+package rfc7807
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// annotation is the problem metadata of an error type, declared with an
+// "rfc7807" tag on a blank field:
+//
+//	type NotFoundError struct {
+//		_  struct{} `rfc7807:"title=Not Found,status=404"`
+//		ID int      `json:"id"`
+//	}
+type annotation struct {
+	title  string
+	status int
+}
+
+// parseAnnotation returns the annotation of the struct type t, if it has one.
+func parseAnnotation(t reflect.Type) (annotation, bool, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return annotation{}, false, nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("rfc7807")
+		if field.Name != "_" || !ok {
+			continue
+		}
+
+		a := annotation{}
+		for _, pair := range strings.Split(tag, ",") {
+			key, value := pair, ""
+			if i := strings.Index(pair, "="); i >= 0 {
+				key, value = pair[:i], pair[i+1:]
+			}
+
+			switch strings.TrimSpace(key) {
+			case "title":
+				a.title = strings.TrimSpace(value)
+			case "status":
+				status, err := strconv.Atoi(strings.TrimSpace(value))
+				if err != nil {
+					return annotation{}, false, fmt.Errorf("rfc7807: invalid status %q in annotation of %s", value, t)
+				}
+				a.status = status
+			default:
+				return annotation{}, false, fmt.Errorf("rfc7807: unknown key %q in annotation of %s", key, t)
+			}
+		}
+
+		return a, true, nil
+	}
+
+	return annotation{}, false, nil
+}
+
+// RegisterError registers a Doc for the annotated error type of err, with the
+// title and, as DefaultStatus, the status of its annotation.
+func (rfc7807 *RFC7807) RegisterError(err error, description string, options ...DocOption) (problemHandlerFunc, error) {
+	a, ok, aErr := parseAnnotation(reflect.TypeOf(err))
+	if aErr != nil {
+		return nil, aErr
+	}
+	if !ok {
+		return nil, fmt.Errorf("rfc7807: %T has no rfc7807 annotation", err)
+	}
+
+	if a.status != 0 {
+		options = append([]DocOption{DefaultStatus(a.status)}, options...)
+	}
+	return rfc7807.Doc(a.title, description, options...)
+}
+
+// WriteError writes the problem annotated on the first error of the chain of
+// err that has an annotation, with its message as detail and its exported
+// fields as extensions, as ErrorStruct does. Without one, it falls back to
+// ErrorWithCause with status 500. A missing status defaults as in ErrorDefault.
+func (rfc7807 *RFC7807) WriteError(w http.ResponseWriter, err error) int {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		a, ok, aErr := parseAnnotation(reflect.TypeOf(e))
+		if aErr != nil {
+			rfc7807.logf("%v", aErr)
+		}
+		if !ok {
+			continue
+		}
+
+		status := a.status
+		if status == 0 {
+			status = rfc7807.defaultStatus(a.title)
+		}
+		return rfc7807.error(w, nil, a.title, status, e.Error(), structExtensions(e))
+	}
+
+	return rfc7807.ErrorWithCause(w, http.StatusInternalServerError, err)
+}
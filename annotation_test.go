@@ -0,0 +1,102 @@
+package rfc7807
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type notFoundError struct {
+	_  struct{} `rfc7807:"title=NotFound,status=404"`
+	ID int      `json:"id"`
+}
+
+func (err notFoundError) Error() string {
+	return fmt.Sprintf("no user %d", err.ID)
+}
+
+type conflictError struct {
+	_ struct{} `rfc7807:" title = Conflict "`
+}
+
+func (err *conflictError) Error() string {
+	return "version mismatch"
+}
+
+func TestParseAnnotation(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  annotation
+		ok    bool
+		err   string
+	}{
+		{"title and status", notFoundError{}, annotation{title: "NotFound", status: 404}, true, ""},
+		{"pointer", &notFoundError{}, annotation{title: "NotFound", status: 404}, true, ""},
+		{"title only", &conflictError{}, annotation{title: "Conflict"}, true, ""},
+		{"no annotation", struct{ Name string }{}, annotation{}, false, ""},
+		{"named field", struct {
+			Name string `rfc7807:"title=Ignored"`
+		}{}, annotation{}, false, ""},
+		{"not a struct", errors.New("plain"), annotation{}, false, ""},
+		{"invalid status", struct {
+			_ struct{} `rfc7807:"title=Teapot,status=teapot"`
+		}{}, annotation{}, false, `invalid status "teapot"`},
+		{"unknown key", struct {
+			_ struct{} `rfc7807:"title=Teapot,code=418"`
+		}{}, annotation{}, false, `unknown key "code"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, ok, err := parseAnnotation(reflect.TypeOf(test.value))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("parseAnnotation() error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil || ok != test.ok || a != test.want {
+				t.Errorf("parseAnnotation() = %+v, %v, %v, want %+v, %v", a, ok, err, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	problems := New("http://example.com/errors", WithPrettyQueryParam("pretty"))
+	if _, err := problems.RegisterError(notFoundError{}, "no such user"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.RegisterError(&conflictError{}, "", DefaultStatus(409)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.RegisterError(errors.New("plain"), ""); err == nil {
+		t.Error("RegisterError() accepted an error without an annotation")
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"annotated", notFoundError{ID: 7}, `{"type":"http://example.com/errors/NotFound.html","title":"NotFound","status":404,"detail":"no user 7","id":7}`},
+		{"wrapped", fmt.Errorf("loading profile: %w", notFoundError{ID: 7}), `{"type":"http://example.com/errors/NotFound.html","title":"NotFound","status":404,"detail":"no user 7","id":7}`},
+		{"registered status", &conflictError{}, `{"type":"http://example.com/errors/Conflict.html","title":"Conflict","status":409,"detail":"version mismatch"}`},
+		{"not annotated", errors.New("disk full"), `{"title":"Internal Server Error","status":500,"detail":"disk full"}`},
+		{"nil", nil, `{"title":"Internal Server Error","status":500,"detail":""}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			status := problems.WriteError(w, test.err)
+			if status != w.Code || w.Body.String() != test.want+"\n" {
+				t.Errorf("WriteError() = %d %s, want %s", status, w.Body.String(), test.want)
+			}
+		})
+	}
+}
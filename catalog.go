@@ -0,0 +1,75 @@
+package rfc7807
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ProblemDef is the definition of a problem in a catalog. See LoadCatalog.
+type ProblemDef struct {
+	Title       string                 `json:"title"`
+	Status      int                    `json:"status,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Slug        string                 `json:"slug,omitempty"`
+	Extensions  map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// LoadCatalog reads a JSON array of problem definitions, for RegisterAll:
+//
+//	[{"title": "Not Found", "status": 404, "description": "...", "slug": "not-found"}]
+//
+// Only JSON is supported here to keep the package free of a YAML dependency;
+// a YAML catalog can be decoded into []ProblemDef by the caller instead.
+func LoadCatalog(r io.Reader) ([]ProblemDef, error) {
+	defs := []ProblemDef{}
+	if err := json.NewDecoder(r).Decode(&defs); err != nil {
+		return nil, fmt.Errorf("rfc7807: failed to decode catalog: %v", err)
+	}
+
+	for i, def := range defs {
+		if def.Title == "" {
+			return nil, fmt.Errorf("rfc7807: catalog entry %d has no title", i)
+		}
+	}
+
+	return defs, nil
+}
+
+// RegisterAll registers every definition with Doc, and stops at the first error.
+func (rfc7807 *RFC7807) RegisterAll(defs []ProblemDef) error {
+	for _, def := range defs {
+		options := []DocOption{}
+		if def.Status != 0 {
+			options = append(options, DefaultStatus(def.Status))
+		}
+		if def.Slug != "" {
+			options = append(options, Slug(def.Slug))
+		}
+		if len(def.Extensions) > 0 {
+			options = append(options, DefaultExtensions(sortedExtensions(def.Extensions)...))
+		}
+
+		if _, err := rfc7807.Doc(def.Title, def.Description, options...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedExtensions(values map[string]interface{}) []*Extension {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	extensions := make([]*Extension, 0, len(keys))
+	for _, key := range keys {
+		extensions = append(extensions, Ext(key, values[key]))
+	}
+
+	return extensions
+}
@@ -0,0 +1,75 @@
+package rfc7807
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		catalog string
+		want    []ProblemDef
+		err     string
+	}{
+		{"empty", `[]`, []ProblemDef{}, ""},
+		{"full", `[{"title": "Out of Credit", "status": 403, "description": "No credit left.", "slug": "out-of-credit", "extensions": {"balance": 0}}]`, []ProblemDef{
+			{Title: "Out of Credit", Status: 403, Description: "No credit left.", Slug: "out-of-credit", Extensions: map[string]interface{}{"balance": float64(0)}},
+		}, ""},
+		{"invalid JSON", `[{"title": }]`, nil, "rfc7807: failed to decode catalog: "},
+		{"not an array", `{"title": "Out of Credit"}`, nil, "rfc7807: failed to decode catalog: "},
+		{"untitled", `[{"title": "Out of Credit"}, {"status": 404}]`, nil, "rfc7807: catalog entry 1 has no title"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defs, err := LoadCatalog(strings.NewReader(test.catalog))
+			if test.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+					t.Errorf("LoadCatalog() error = %v, want %q...", err, test.err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(defs, test.want) {
+				t.Errorf("LoadCatalog() = %v, %v, want %v", defs, err, test.want)
+			}
+		})
+	}
+}
+
+func TestRegisterAll(t *testing.T) {
+	problems := New("http://example.com/errors", WithPrettyQueryParam("pretty"))
+	defs := []ProblemDef{
+		{Title: "Out of Credit", Status: 403, Description: "No credit left.", Slug: "out-of-credit", Extensions: map[string]interface{}{"currency": "EUR", "balance": 0}},
+		{Title: "GoneAway"},
+	}
+	if err := problems.RegisterAll(defs); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	problems.ErrorDefault(w, "Out of Credit", "")
+	if got, want := w.Body.String(), `{"type":"http://example.com/errors/out-of-credit.html","title":"Out of Credit","status":403,"detail":"","balance":0,"currency":"EUR"}`+"\n"; got != want {
+		t.Errorf("problem = %s, want %s", got, want)
+	}
+	if page := serve(problems, http.MethodGet, "/out-of-credit.html", nil); page.Code != http.StatusOK || !strings.Contains(page.Body.String(), "No credit left.") {
+		t.Errorf("GET /out-of-credit.html = %d %s, want the description", page.Code, page.Body.String())
+	}
+	if page := serve(problems, http.MethodGet, "/GoneAway.html", nil); page.Code != http.StatusOK {
+		t.Errorf("GET /GoneAway.html = %d, want 200", page.Code)
+	}
+
+	// Registration stops at the first error.
+	err := problems.RegisterAll([]ProblemDef{{Title: "No Credit", Slug: "out-of-credit"}, {Title: "Never Registered"}})
+	if err == nil || !strings.Contains(err.Error(), "collides") {
+		t.Errorf("RegisterAll() error = %v, want the slug collision", err)
+	}
+	w = httptest.NewRecorder()
+	problems.Error(w, "Never Registered", 400, "")
+	if got := decode(t, w)["type"]; got != nil {
+		t.Errorf("type = %v, want none as RegisterAll() stopped at the error", got)
+	}
+}
@@ -0,0 +1,78 @@
+package rfc7807
+
+import (
+	"context"
+	"net/http"
+)
+
+type problemKey struct{}
+
+// problemSlot is shared by a request context and those derived from it, so a
+// problem set by an inner handler is seen by the middleware that installed it.
+type problemSlot struct {
+	problem *Problem
+}
+
+// WithProblem attaches problem to ctx for an outer middleware, such as
+// ContextProblems, to decide whether to write it. Under ContextProblems the
+// returned context need not be passed on: the problem is visible to it anyway.
+func WithProblem(ctx context.Context, problem *Problem) context.Context {
+	if slot, ok := ctx.Value(problemKey{}).(*problemSlot); ok {
+		slot.problem = problem
+		return ctx
+	}
+
+	return context.WithValue(ctx, problemKey{}, &problemSlot{problem: problem})
+}
+
+// ProblemFromContext returns the problem attached to ctx with WithProblem.
+func ProblemFromContext(ctx context.Context) (*Problem, bool) {
+	slot, ok := ctx.Value(problemKey{}).(*problemSlot)
+	if !ok || slot.problem == nil {
+		return nil, false
+	}
+
+	return slot.problem, true
+}
+
+// ContextProblems is a middleware that writes the problem attached with
+// WithProblem by the next handler, unless that handler already responded.
+func (rfc7807 *RFC7807) ContextProblems(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		slot := &problemSlot{}
+		writer := &respondWriter{ResponseWriter: aWriter}
+		next.ServeHTTP(writer, aRequest.WithContext(context.WithValue(aRequest.Context(), problemKey{}, slot)))
+
+		if !writer.responded && slot.problem != nil {
+			rfc7807.WriteProblem(aWriter, aRequest, slot.problem)
+		}
+	})
+}
+
+// respondWriter records whether anything was sent through it.
+type respondWriter struct {
+	http.ResponseWriter
+	responded bool
+}
+
+func (w *respondWriter) WriteHeader(status int) {
+	w.responded = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *respondWriter) Write(b []byte) (int, error) {
+	w.responded = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *respondWriter) Flush() {
+	w.responded = true
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *respondWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,80 @@
+package rfc7807
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithProblem(t *testing.T) {
+	problem := &Problem{Title: "Out of Credit", Status: 403}
+
+	if _, ok := ProblemFromContext(context.Background()); ok {
+		t.Error("ProblemFromContext() found a problem in an empty context")
+	}
+
+	ctx := WithProblem(context.Background(), problem)
+	if got, ok := ProblemFromContext(ctx); !ok || got != problem {
+		t.Errorf("ProblemFromContext() = %v, %v, want the attached problem", got, ok)
+	}
+
+	// A nil problem counts as none.
+	if _, ok := ProblemFromContext(WithProblem(context.Background(), nil)); ok {
+		t.Error("ProblemFromContext() found a nil problem")
+	}
+}
+
+func TestContextProblems(t *testing.T) {
+	problems := New("http://example.com/errors", WithPrettyQueryParam("pretty"))
+	problem := &Problem{Title: "Out of Credit", Status: 403, Detail: "low"}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		body    string
+	}{
+		{"attached", func(w http.ResponseWriter, r *http.Request) {
+			// The derived context is dropped on purpose: the middleware sees the problem anyway.
+			WithProblem(r.Context(), problem)
+		}, 403, `{"title":"Out of Credit","status":403,"detail":"low"}` + "\n"},
+		{"responded", func(w http.ResponseWriter, r *http.Request) {
+			WithProblem(r.Context(), problem)
+			w.WriteHeader(http.StatusAccepted)
+		}, 202, ""},
+		{"flushed", func(w http.ResponseWriter, r *http.Request) {
+			WithProblem(r.Context(), problem)
+			w.(http.Flusher).Flush()
+		}, 200, ""},
+		{"none", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}, 200, "ok"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := serve(problems.ContextProblems(test.handler), http.MethodGet, "/", nil)
+			if w.Code != test.status || w.Body.String() != test.body {
+				t.Errorf("response = %d %q, want %d %q", w.Code, w.Body.String(), test.status, test.body)
+			}
+		})
+	}
+}
+
+func TestContextProblemsUnwrap(t *testing.T) {
+	problems := New("http://example.com/errors")
+	deadline := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	handler := problems.ContextProblems(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+			t.Errorf("SetWriteDeadline() = %v", err)
+		}
+	}))
+
+	w := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !w.deadline.Equal(deadline) {
+		t.Errorf("deadline = %v, want %v", w.deadline, deadline)
+	}
+}
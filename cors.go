@@ -0,0 +1,56 @@
+package rfc7807
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORS configures the cross-origin headers of doc routes. See WithDocCORS.
+type CORS struct {
+	// AllowOrigin is the Access-Control-Allow-Origin value, e.g. "*".
+	AllowOrigin string
+	// AllowMethods defaults to GET, HEAD and OPTIONS.
+	AllowMethods []string
+	AllowHeaders []string
+	// MaxAge is how long, in seconds, a preflight result may be cached. Zero omits it.
+	MaxAge int
+}
+
+// WithDocCORS adds CORS headers to doc routes and answers preflight requests
+// with 204 No Content.
+func WithDocCORS(cors CORS) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.cors = &cors
+	}
+}
+
+// handle sets the CORS headers and reports whether the request was a preflight
+// that has been answered.
+func (cors *CORS) handle(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Access-Control-Allow-Origin", cors.AllowOrigin)
+	if cors.AllowOrigin != "*" {
+		w.Header().Add("Vary", "Origin")
+	}
+
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		return false
+	}
+
+	methods := cors.AllowMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+	}
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+
+	if len(cors.AllowHeaders) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(cors.AllowHeaders, ", "))
+	}
+
+	if cors.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(cors.MaxAge))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,57 @@
+package rfc7807
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestWithDocCORS(t *testing.T) {
+	tests := []struct {
+		name    string
+		cors    CORS
+		method  string
+		header  http.Header
+		status  int
+		headers map[string]string
+	}{
+		{
+			name: "any origin", cors: CORS{AllowOrigin: "*"}, method: http.MethodGet,
+			header: http.Header{"Origin": {"http://app.example.com"}},
+			status: http.StatusOK, headers: map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			name: "one origin", cors: CORS{AllowOrigin: "http://app.example.com"}, method: http.MethodGet,
+			header: http.Header{"Origin": {"http://app.example.com"}},
+			status: http.StatusOK, headers: map[string]string{"Access-Control-Allow-Origin": "http://app.example.com", "Vary": "Origin"},
+		},
+		{
+			name: "preflight", cors: CORS{AllowOrigin: "*"}, method: http.MethodOptions,
+			header: http.Header{"Origin": {"http://app.example.com"}, "Access-Control-Request-Method": {"GET"}},
+			status: http.StatusNoContent, headers: map[string]string{"Access-Control-Allow-Methods": "GET, HEAD, OPTIONS", "Access-Control-Allow-Headers": "", "Access-Control-Max-Age": ""},
+		},
+		{
+			name: "configured preflight", cors: CORS{AllowOrigin: "*", AllowMethods: []string{"GET"}, AllowHeaders: []string{"Accept", "X-Audience"}, MaxAge: 600}, method: http.MethodOptions,
+			header: http.Header{"Origin": {"http://app.example.com"}, "Access-Control-Request-Method": {"GET"}},
+			status: http.StatusNoContent, headers: map[string]string{"Access-Control-Allow-Methods": "GET", "Access-Control-Allow-Headers": "Accept, X-Audience", "Access-Control-Max-Age": "600"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", WithDocCORS(test.cors))
+			if _, err := problems.Doc("NotFound", "missing"); err != nil {
+				t.Fatal(err)
+			}
+
+			w := serve(problems, test.method, "/NotFound.html", test.header)
+			if w.Code != test.status {
+				t.Errorf("status = %d, want %d", w.Code, test.status)
+			}
+			for name, want := range test.headers {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package rfc7807
+
+import (
+	"net/http"
+	"sync"
+)
+
+var (
+	defaultMutex    sync.Mutex
+	defaultInstance *RFC7807
+)
+
+// Default returns the RFC7807 behind the package-level Error and Doc, creating
+// it with an empty base URL on first use. Like http.DefaultServeMux, it saves
+// small services from threading an instance around.
+func Default() *RFC7807 {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+
+	if defaultInstance == nil {
+		defaultInstance = New("")
+	}
+	return defaultInstance
+}
+
+// SetBaseURL sets the base URL of the Default instance. Type URLs are resolved
+// when docs are registered, so call it first, during initialization. It is safe
+// to call while the instance is in use.
+func SetBaseURL(baseURL string) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+
+	if defaultInstance == nil {
+		defaultInstance = New(baseURL)
+		return
+	}
+
+	defaultInstance.registry.Lock()
+	defaultInstance.URL = baseURL
+	defaultInstance.registry.Unlock()
+}
+
+// Error calls Error on the Default instance.
+func Error(w http.ResponseWriter, title string, status int, detail string, extensions ...*Extension) int {
+	return Default().Error(w, title, status, detail, extensions...)
+}
+
+// Doc calls Doc on the Default instance.
+func Doc(title string, description string, options ...DocOption) (problemHandlerFunc, error) {
+	return Default().Doc(title, description, options...)
+}
@@ -0,0 +1,90 @@
+package rfc7807
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// resetDefault drops the Default instance, for tests that start from scratch.
+func resetDefault(t *testing.T) {
+	defaultMutex.Lock()
+	defaultInstance = nil
+	defaultMutex.Unlock()
+
+	t.Cleanup(func() {
+		defaultMutex.Lock()
+		defaultInstance = nil
+		defaultMutex.Unlock()
+	})
+}
+
+func TestDefaultConcurrentInit(t *testing.T) {
+	resetDefault(t)
+
+	instances := make([]*RFC7807, 32)
+	var wg sync.WaitGroup
+	for i := range instances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			instances[i] = Default()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, instance := range instances {
+		if instance == nil || instance != instances[0] {
+			t.Fatalf("goroutine %d got instance %p, want %p", i, instance, instances[0])
+		}
+	}
+}
+
+func TestSetBaseURL(t *testing.T) {
+	resetDefault(t)
+	SetBaseURL("http://example.com/errors")
+	if _, err := Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	Error(w, "NotFound", 404, "")
+	if got := decode(t, w)["type"]; got != "http://example.com/errors/NotFound.html" {
+		t.Errorf("type = %v, want it under the base URL", got)
+	}
+	if Default().URL != "http://example.com/errors" {
+		t.Errorf("Default().URL = %q", Default().URL)
+	}
+}
+
+func TestSetBaseURLConcurrent(t *testing.T) {
+	resetDefault(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := Doc(fmt.Sprintf("Problem%d", i), ""); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			if got := Error(w, fmt.Sprintf("Problem%d", i), 400, ""); got != 400 {
+				t.Errorf("Error() = %d, want 400", got)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			SetBaseURL(fmt.Sprintf("http://example.com/errors%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	if stats := Default().Stats(); len(stats) != 8 {
+		t.Errorf("Stats() = %v, want the 8 problems registered", stats)
+	}
+}
@@ -0,0 +1,37 @@
+package rfc7807
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// wantsDescriptor reports whether r asks a doc route for JSON rather than HTML.
+func wantsDescriptor(r *http.Request) bool {
+	return bestOffer(r.Header.Get("Accept"), []string{"text/html", "application/json"}) == "application/json"
+}
+
+// writeDescriptor writes the problem type of doc as JSON, for clients that
+// dereference type URLs:
+//
+//	{"type": "...", "title": "Out of Credit", "status": 403, "extensions": [{"name": "balance", "description": "..."}]}
+//
+// status and extensions are present when set with DefaultStatus and Fields.
+func (rfc7807 *RFC7807) writeDescriptor(w http.ResponseWriter, r *http.Request, doc *doc) {
+	descriptor := extensionObject{Ext("type", rfc7807.docURL(r, doc)), Ext("title", doc.title)}
+	if doc.status != 0 {
+		descriptor = append(descriptor, Ext("status", doc.status))
+	}
+	if len(doc.fields) > 0 {
+		fields := make([]extensionObject, 0, len(doc.fields))
+		for _, field := range doc.fields {
+			fields = append(fields, extensionObject{Ext("name", field.Name), Ext("description", field.Description)})
+		}
+		descriptor = append(descriptor, Ext("extensions", fields))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", rfc7807.indent(r))
+	encoder.Encode(descriptor)
+}
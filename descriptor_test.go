@@ -0,0 +1,61 @@
+package rfc7807
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDescriptor(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("OutOfCredit", "no credit", DefaultStatus(403), Fields(Field{Name: "balance", Description: "The current balance."}, Field{Name: "accounts", Description: "Accounts to credit."})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		want   map[string]interface{}
+	}{
+		{"full", "/OutOfCredit.html", "application/json", map[string]interface{}{
+			"type":   "http://example.com/errors/OutOfCredit.html",
+			"title":  "OutOfCredit",
+			"status": float64(403),
+			"extensions": []interface{}{
+				map[string]interface{}{"name": "balance", "description": "The current balance."},
+				map[string]interface{}{"name": "accounts", "description": "Accounts to credit."},
+			},
+		}},
+		{"minimal", "/NotFound.html", "text/html;q=0.5, application/json", map[string]interface{}{
+			"type":  "http://example.com/errors/NotFound.html",
+			"title": "NotFound",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := serve(problems, http.MethodGet, test.path, http.Header{"Accept": {test.accept}})
+			if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+				t.Fatalf("GET %s = %d %q, want a JSON descriptor", test.path, w.Code, w.Header().Get("Content-Type"))
+			}
+			if got := decode(t, w); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("descriptor = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	for _, accept := range []string{"", "text/html", "text/html, application/json;q=0.9", "*/*"} {
+		w := serve(problems, http.MethodGet, "/OutOfCredit.html", http.Header{"Accept": {accept}})
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), "no credit") {
+			t.Errorf("Accept %q: got %q, want the HTML page", accept, w.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(strings.Join(w.Header()["Vary"], ","), "Accept") {
+			t.Errorf("Accept %q: Vary = %q, want Accept", accept, w.Header()["Vary"])
+		}
+	}
+}
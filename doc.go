@@ -0,0 +1,285 @@
+package rfc7807
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const publicVariant = "public"
+
+// DocOption configures a single problem registered with the Doc family.
+type DocOption func(*doc)
+
+// DefaultStatus sets the status ErrorDefault uses for the problem.
+func DefaultStatus(status int) DocOption {
+	return func(doc *doc) {
+		doc.status = status
+	}
+}
+
+// Slug names the doc page of the problem, instead of deriving it from the title.
+func Slug(slug string) DocOption {
+	return func(doc *doc) {
+		doc.slug = slug
+	}
+}
+
+// Code gives the problem a stable machine code, which names its doc page in place
+// of the title, so type URLs survive title changes. Slug still takes precedence.
+func Code(code string) DocOption {
+	return func(doc *doc) {
+		doc.code = code
+	}
+}
+
+// Localized adds a translation of the doc page into lang (e.g. "ja"), served
+// next to it as "<slug>.<lang>.html". ErrorRequest points type at it for
+// requests whose Accept-Language prefers lang. It is ignored with
+// WithSingleDocPage.
+func Localized(lang string, html []byte) DocOption {
+	return func(d *doc) {
+		if d.locales == nil {
+			d.locales = map[string]*doc{}
+		}
+		d.locales[strings.ToLower(lang)] = &doc{title: d.title, html: html}
+	}
+}
+
+// DefaultExtensions adds extensions to every emission of the problem. Extensions
+// passed at the call site win over them.
+func DefaultExtensions(extensions ...*Extension) DocOption {
+	return func(doc *doc) {
+		doc.extensions = append(doc.extensions, extensions...)
+	}
+}
+
+// NoCache serves the doc page with "Cache-Control: no-cache" and no ETag, for
+// docs whose content changes often.
+func NoCache() DocOption {
+	return func(doc *doc) {
+		doc.noCache = true
+	}
+}
+
+// AllowExtensions restricts the problem to the extension keys listed. Others are
+// dropped with a logged warning when the problem is written.
+func AllowExtensions(keys ...string) DocOption {
+	return func(doc *doc) {
+		if doc.allowedExtensions == nil {
+			doc.allowedExtensions = map[string]bool{}
+		}
+		for _, key := range keys {
+			doc.allowedExtensions[key] = true
+		}
+	}
+}
+
+// NoBody writes the problem as headers and status only, without a JSON body.
+// Problems with status 204 or 304 are always written this way.
+func NoBody() DocOption {
+	return func(doc *doc) {
+		doc.noBody = true
+	}
+}
+
+// Headers sets header on every emission of the problem.
+func Headers(header http.Header) DocOption {
+	return func(doc *doc) {
+		if doc.header == nil {
+			doc.header = http.Header{}
+		}
+		for key, values := range header {
+			doc.header[key] = append(doc.header[key], values...)
+		}
+	}
+}
+
+// Field documents an extension member a problem can carry.
+type Field struct {
+	Name        string
+	Description string
+}
+
+// Fields documents the extension members of the problem. Doc pages list them
+// in a table, and custom templates get them as .Fields.
+func Fields(fields ...Field) DocOption {
+	return func(doc *doc) {
+		doc.fields = append(doc.fields, fields...)
+	}
+}
+
+// docFields returns the fields documented by options, which templates need
+// before the doc is registered.
+func docFields(options []DocOption) []Field {
+	d := &doc{}
+	for _, option := range options {
+		option(d)
+	}
+
+	return d.fields
+}
+
+type doc struct {
+	// emitted counts writes of the problem for Stats. It comes first to be
+	// 64-bit aligned for atomic access on 32-bit platforms.
+	emitted int64
+
+	title    string
+	path     string
+	fragment string
+	url      string
+	page     func(status int, detail string) ([]byte, error)
+
+	variants map[string][]byte
+
+	allowedExtensions map[string]bool
+	status            int
+	noCache           bool
+	slug              string
+	extensions        []*Extension
+	noBody            bool
+	header            http.Header
+	gzipped           []byte
+	fields            []Field
+	code              string
+	locales           map[string]*doc
+	profile           *doc
+	external          bool
+
+	once sync.Once
+	bake func() ([]byte, error)
+	html []byte
+	err  error
+}
+
+// bytes returns the doc HTML, baking it exactly once for lazy docs even when
+// the first requests arrive concurrently.
+func (doc *doc) bytes() ([]byte, error) {
+	doc.once.Do(func() {
+		if doc.bake != nil {
+			doc.html, doc.err = doc.bake()
+		}
+	})
+
+	return doc.html, doc.err
+}
+
+func (doc *doc) render(status int, detail string) ([]byte, error) {
+	if doc.page != nil {
+		return doc.page(status, detail)
+	}
+
+	html, err := doc.bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return injectDetail(html, detail), nil
+}
+
+func injectDetail(html []byte, detail string) []byte {
+	p := []byte(`<p class="problem-detail">` + template.HTMLEscapeString(detail) + `</p>`)
+
+	at := 0
+	if i := bytes.Index(bytes.ToLower(html), []byte("<body")); i >= 0 {
+		if j := bytes.IndexByte(html[i:], '>'); j >= 0 {
+			at = i + j + 1
+		}
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(html)+len(p)))
+	buf.Write(html[:at])
+	buf.Write(p)
+	buf.Write(html[at:])
+	return buf.Bytes()
+}
+
+// styled inserts the WithDocStylesheet elements at the end of the head of html.
+func (rfc7807 *RFC7807) styled(html []byte) []byte {
+	if rfc7807.docHead == "" {
+		return html
+	}
+
+	i := bytes.Index(bytes.ToLower(html), []byte("</head>"))
+	if i < 0 {
+		return html
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(html)+len(rfc7807.docHead)))
+	buf.Write(html[:i])
+	buf.WriteString(rfc7807.docHead)
+	buf.Write(html[i:])
+	return buf.Bytes()
+}
+
+// layout wraps the body of html in the WithDocLayout layout.
+func (rfc7807 *RFC7807) layout(title string, html []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, len(html)+1024))
+	data := map[string]interface{}{
+		"Title":   title,
+		"Head":    template.HTML(rfc7807.docHead),
+		"Content": template.HTML(bodyContent(html)),
+	}
+	if err := rfc7807.docLayout.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("rfc7807: failed to execute doc layout for %q: %w", title, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeDoc writes a doc page. Unless noCache is set, it is tagged with an ETag
+// and a matching If-None-Match gets 304 Not Modified.
+func (rfc7807 *RFC7807) writeDoc(w http.ResponseWriter, r *http.Request, html []byte, noCache bool) {
+	if noCache {
+		w.Header().Set("Cache-Control", "no-cache")
+		rfc7807.writeHTML(w, http.StatusOK, html)
+		return
+	}
+
+	hash := fnv.New64a()
+	hash.Write(html)
+	etag := fmt.Sprintf(`"%x"`, hash.Sum64())
+
+	w.Header().Set("ETag", etag)
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	rfc7807.writeHTML(w, http.StatusOK, html)
+}
+
+// writeGzipDoc writes the precompressed form of a doc page.
+func (rfc7807 *RFC7807) writeGzipDoc(w http.ResponseWriter, r *http.Request, gzipped []byte, noCache bool) {
+	w.Header().Set("Content-Encoding", "gzip")
+	rfc7807.writeDoc(w, r, gzipped, noCache)
+}
+
+// gzipBytes compresses html at the best compression level, since it is done
+// once per doc.
+func gzipBytes(html []byte) []byte {
+	var buf bytes.Buffer
+	writer, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	writer.Write(html)
+	writer.Close()
+	return buf.Bytes()
+}
+
+// etagMatch reports whether an If-None-Match header matches etag, using the
+// weak comparison of RFC 7232.
+func etagMatch(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
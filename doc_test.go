@@ -0,0 +1,279 @@
+package rfc7807
+
+import (
+	"bytes"
+	"compress/gzip"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazyDocBakedOnce(t *testing.T) {
+	var bakes int32
+	funcs := template.FuncMap{"bake": func() string {
+		atomic.AddInt32(&bakes, 1)
+		return ""
+	}}
+
+	problems := New("http://example.com/errors", WithLazyDocs())
+	if _, err := problems.TemplateDocFuncs("NotFound", "missing", "{{bake}}<h1>{{.Title}}</h1>", funcs); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&bakes); n != 0 {
+		t.Fatalf("doc baked %d times at registration, want 0", n)
+	}
+
+	pages := make([]string, 64)
+	var wg sync.WaitGroup
+	for i := range pages {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pages[i] = serve(problems, http.MethodGet, "/NotFound.html", nil).Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&bakes); n != 1 {
+		t.Errorf("doc baked %d times, want 1", n)
+	}
+	for i, page := range pages {
+		if page != "<h1>NotFound</h1>" {
+			t.Errorf("request %d got page %q", i, page)
+		}
+	}
+}
+
+func TestAllowExtensions(t *testing.T) {
+	var logs bytes.Buffer
+	problems := New("http://example.com/errors", WithLogger(log.New(&logs, "", 0)))
+	if _, err := problems.Doc("Out of Credit", "", AllowExtensions("balance", "accounts")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.Doc("Open", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	problems.Error(w, "Out of Credit", 403, "", Ext("balance", 30), Ext("debug", "x"), Ext("accounts", []string{}))
+	problem := decode(t, w)
+	for _, key := range []string{"balance", "accounts"} {
+		if _, ok := problem[key]; !ok {
+			t.Errorf("allowed extension %q dropped", key)
+		}
+	}
+	if _, ok := problem["debug"]; ok {
+		t.Error("disallowed extension debug written")
+	}
+	if !strings.Contains(logs.String(), `"debug"`) {
+		t.Errorf("no warning for the dropped extension, logged %q", logs.String())
+	}
+
+	w = httptest.NewRecorder()
+	problems.Error(w, "Open", 403, "", Ext("debug", "x"))
+	if _, ok := decode(t, w)["debug"]; !ok {
+		t.Error("extension dropped from a problem without an allowlist")
+	}
+}
+
+func TestNoCache(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("Cached", "stable"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.Doc("Volatile", "changing", NoCache()); err != nil {
+		t.Fatal(err)
+	}
+
+	cached := serve(problems, http.MethodGet, "/Cached.html", nil)
+	etag := cached.Header().Get("ETag")
+	if etag == "" || cached.Header().Get("Cache-Control") != "" {
+		t.Errorf("cacheable doc headers = %v, want an ETag only", cached.Header())
+	}
+	if w := serve(problems, http.MethodGet, "/Cached.html", http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("revalidation = %d %q, want an empty 304", w.Code, w.Body.String())
+	}
+
+	volatile := serve(problems, http.MethodGet, "/Volatile.html", nil)
+	if volatile.Header().Get("ETag") != "" || volatile.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("no-cache doc headers = %v, want Cache-Control: no-cache and no ETag", volatile.Header())
+	}
+	if w := serve(problems, http.MethodGet, "/Volatile.html", http.Header{"If-None-Match": {"*"}}); w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Errorf("no-cache revalidation = %d, want the page", w.Code)
+	}
+}
+
+func TestETagMatch(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"x", "abc"`, true},
+		{`*`, true},
+		{`"abcd"`, false},
+		{``, false},
+	}
+
+	for _, test := range tests {
+		if got := etagMatch(test.ifNoneMatch, `"abc"`); got != test.want {
+			t.Errorf("etagMatch(%q) = %v, want %v", test.ifNoneMatch, got, test.want)
+		}
+	}
+}
+
+func TestNoBody(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("Deprecated", "soft deprecation", Headers(http.Header{"Deprecation": {"true"}})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.Doc("Moved", "", NoBody(), Headers(http.Header{"Location": {"/v2"}})); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		title  string
+		status int
+		header string
+		value  string
+	}{
+		{"204", "Deprecated", http.StatusNoContent, "Deprecation", "true"},
+		{"304", "", http.StatusNotModified, "", ""},
+		{"NoBody", "Moved", http.StatusGone, "Location", "/v2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if got := problems.Error(w, test.title, test.status, "detail"); got != test.status {
+				t.Errorf("Error() = %d, want %d", got, test.status)
+			}
+
+			if w.Code != test.status || w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+				t.Errorf("response = %d %v %q, want %d without a body", w.Code, w.Header(), w.Body.String(), test.status)
+			}
+			if test.header != "" && w.Header().Get(test.header) != test.value {
+				t.Errorf("%s = %q, want %q", test.header, w.Header().Get(test.header), test.value)
+			}
+		})
+	}
+}
+
+func TestWithPrecompressedDocs(t *testing.T) {
+	page := []byte("<html><body><h1>Not Found</h1></body></html>")
+	problems := New("http://example.com/errors", WithPrecompressedDocs())
+	problems.HtmlDoc("NotFound", page)
+
+	gzipped := serve(problems, http.MethodGet, "/NotFound.html", http.Header{"Accept-Encoding": {"br, gzip"}})
+	if gzipped.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", gzipped.Header().Get("Content-Encoding"))
+	}
+	reader, err := gzip.NewReader(gzipped.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decompressed, page) {
+		t.Errorf("decompressed page = %q, want %q", decompressed, page)
+	}
+
+	for _, acceptEncoding := range []string{"", "br", "gzip;q=0"} {
+		w := serve(problems, http.MethodGet, "/NotFound.html", http.Header{"Accept-Encoding": {acceptEncoding}})
+		if w.Header().Get("Content-Encoding") != "" || !bytes.Equal(w.Body.Bytes(), page) {
+			t.Errorf("Accept-Encoding %q: got %q encoded %q, want the plain page", acceptEncoding, w.Body.Bytes(), w.Header().Get("Content-Encoding"))
+		}
+	}
+}
+
+func TestCode(t *testing.T) {
+	problems := New("http://example.com/errors", WithSlugger(Slugify))
+	if _, err := problems.Doc("You do not have enough credit.", "balance too low", Code("out-of-credit")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.Doc("Account frozen", "", Code("frozen"), Slug("account-frozen")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		title string
+		path  string
+		other string
+	}{
+		{"You do not have enough credit.", "/out-of-credit.html", "/you-do-not-have-enough-credit.html"},
+		{"Account frozen", "/account-frozen.html", "/frozen.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			problems.Error(w, test.title, 403, "")
+			problem := decode(t, w)
+			if problem["type"] != "http://example.com/errors"+test.path || problem["title"] != test.title {
+				t.Errorf("type, title = %v, %v, want %q, %q", problem["type"], problem["title"], test.path, test.title)
+			}
+
+			if page := serve(problems, http.MethodGet, test.path, nil); page.Code != http.StatusOK || !strings.Contains(page.Body.String(), test.title) {
+				t.Errorf("GET %s = %d %q, want the doc page", test.path, page.Code, page.Body.String())
+			}
+			if page := serve(problems, http.MethodGet, test.other, nil); page.Code != http.StatusNotFound {
+				t.Errorf("GET %s = %d, want 404", test.other, page.Code)
+			}
+		})
+	}
+}
+
+func TestLocalized(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("NotFound", "missing", Localized("ja", []byte("<p>見つかりません</p>")), Localized("pt-BR", []byte("<p>não encontrado</p>"))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		acceptLanguage string
+		path           string
+	}{
+		{"ja", "/NotFound.ja.html"},
+		{"ja-JP", "/NotFound.ja.html"},
+		{"fr, ja;q=0.5", "/NotFound.ja.html"},
+		{"PT-br", "/NotFound.pt-br.html"},
+		{"ja;q=0, fr", "/NotFound.html"},
+		{"de", "/NotFound.html"},
+		{"", "/NotFound.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.acceptLanguage, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Language", test.acceptLanguage)
+			w := httptest.NewRecorder()
+			problems.ErrorRequest(w, r, "NotFound", 404, "")
+
+			if got := decode(t, w)["type"]; got != "http://example.com/errors"+test.path {
+				t.Errorf("type = %v, want %q", got, test.path)
+			}
+			if page := serve(problems, http.MethodGet, test.path, nil); page.Code != http.StatusOK {
+				t.Errorf("GET %s = %d, want 200", test.path, page.Code)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	problems.Error(w, "NotFound", 404, "")
+	if got := decode(t, w)["type"]; got != "http://example.com/errors/NotFound.html" {
+		t.Errorf("type without a request = %v, want the default page", got)
+	}
+	if page := serve(problems, http.MethodGet, "/NotFound.ja.html", nil).Body.String(); page != "<p>見つかりません</p>" {
+		t.Errorf("translated page = %q", page)
+	}
+}
@@ -0,0 +1,132 @@
+package rfc7807
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+var fastBuffers = sync.Pool{
+	New: func() interface{} {
+		return bytes.NewBuffer(make([]byte, 0, 256))
+	},
+}
+
+// fastPath reports whether a problem with no doc and no extensions comes out as
+// just title, status and detail, with nothing else needing the Problem, so
+// writeFastProblem can be used.
+func (rfc7807 *RFC7807) fastPath(status int) bool {
+	return rfc7807.fallbackDoc == nil && rfc7807.defaultType == "" && len(rfc7807.trailers) == 0 &&
+		rfc7807.errorFormat == FormatRFC7807 && len(rfc7807.finalizers) == 0 &&
+		!rfc7807.generateInstance && rfc7807.adaptiveIndent == 0 && rfc7807.envelope == nil &&
+		(rfc7807.reporter == nil || status < 500)
+}
+
+// writeFastProblem writes the same bytes as writeProblem for a problem made of
+// title, status and detail only, without building a Problem or an encoder.
+func (rfc7807 *RFC7807) writeFastProblem(w http.ResponseWriter, r *http.Request, status int, title string, detail string) {
+	indent := rfc7807.indent(r)
+
+	buf := fastBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer fastBuffers.Put(buf)
+
+	member := func(first bool, key string) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		if indent != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(indent)
+		}
+		buf.WriteString(key)
+		if indent != "" {
+			buf.WriteByte(' ')
+		}
+	}
+
+	buf.WriteByte('{')
+	member(true, `"title":`)
+	writeJSONString(buf, title)
+	member(false, `"status":`)
+	var number [20]byte
+	buf.Write(strconv.AppendInt(number[:0], int64(status), 10))
+	member(false, `"detail":`)
+	writeJSONString(buf, detail)
+	if indent != "" {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("}\n")
+
+	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	w.WriteHeader(status)
+	rfc7807.flush(w)
+	w.Write(buf.Bytes())
+	rfc7807.flush(w)
+}
+
+const hex = "0123456789abcdef"
+
+// controlEscapes holds the escape encoding/json writes for each control
+// character, which is not the same across Go releases (\b and \f became short
+// escapes in Go 1.22).
+var controlEscapes = func() (escapes [0x20]string) {
+	for c := range escapes {
+		quoted, _ := json.Marshal(string(rune(c)))
+		escapes[c] = string(quoted[1 : len(quoted)-1])
+	}
+	return escapes
+}()
+
+// writeJSONString writes s as a JSON string, escaped exactly as encoding/json
+// does with HTML escaping enabled.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch {
+			case c == '"' || c == '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case c < 0x20:
+				buf.WriteString(controlEscapes[c])
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[c>>4])
+				buf.WriteByte(hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
@@ -0,0 +1,97 @@
+package rfc7807
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteFastProblem(t *testing.T) {
+	strings := []string{
+		"Not Found",
+		"",
+		`<script>alert("&")</script>`,
+		"\x00\x01\x08\x0c\x1f\x7f",
+		"line\nbreak\ttab\rreturn \"quote\" \\backslash",
+		"\u2028line\u2029separators",
+		"invalid \xff\xfe utf-8 \xc3\x28 \xed\xa0\x80",
+		"日本語 🙂",
+	}
+
+	tests := []struct {
+		name    string
+		options []Option
+	}{
+		{"indented", nil},
+		{"compact", []Option{WithPrettyQueryParam("pretty")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", test.options...)
+			for _, s := range strings {
+				fast := httptest.NewRecorder()
+				problems.writeFastProblem(fast, nil, 404, s, s)
+
+				general := httptest.NewRecorder()
+				problems.writeProblem(general, nil, &Problem{Title: s, Status: 404, Detail: s}, nil)
+
+				if fast.Body.String() != general.Body.String() {
+					t.Errorf("%q:\nfast    %q\ngeneral %q", s, fast.Body.String(), general.Body.String())
+				}
+				if fast.Code != general.Code || fast.Header().Get("Content-Type") != general.Header().Get("Content-Type") {
+					t.Errorf("%q: fast %d %v, general %d %v", s, fast.Code, fast.Header(), general.Code, general.Header())
+				}
+			}
+		})
+	}
+}
+
+func TestFastPathTaken(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if allocs := testing.AllocsPerRun(100, func() {
+		problems.Error(discardWriter{header: http.Header{}}, "", 404, "no such user")
+	}); allocs > 10 {
+		t.Errorf("Error allocates %v times, want the fast path", allocs)
+	}
+}
+
+// discardWriter is a ResponseWriter that drops what is written, so benchmarks
+// measure the problem alone.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w discardWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w discardWriter) WriteHeader(status int) {}
+
+func BenchmarkError(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		options []Option
+	}{
+		{"fast", nil},
+		// A finalizer needs the Problem, which rules out the fast path.
+		{"general", []Option{WithFinalizer(func(*Problem, *http.Request) {})}},
+	}
+
+	for _, benchmark := range benchmarks {
+		b.Run(benchmark.name, func(b *testing.B) {
+			problems := New("http://example.com/errors", benchmark.options...)
+			w := discardWriter{header: http.Header{}}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				problems.Error(w, "", http.StatusNotFound, "no such user")
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package rfc7807
+
+import "net/http"
+
+// GRPCStatus maps gRPC status codes (the values of google.golang.org/grpc/codes.Code)
+// to HTTP statuses, following the mapping used by grpc-gateway.
+var GRPCStatus = map[uint32]int{
+	0:  http.StatusOK,                  // OK
+	1:  499,                            // Canceled
+	2:  http.StatusInternalServerError, // Unknown
+	3:  http.StatusBadRequest,          // InvalidArgument
+	4:  http.StatusGatewayTimeout,      // DeadlineExceeded
+	5:  http.StatusNotFound,            // NotFound
+	6:  http.StatusConflict,            // AlreadyExists
+	7:  http.StatusForbidden,           // PermissionDenied
+	8:  http.StatusTooManyRequests,     // ResourceExhausted
+	9:  http.StatusBadRequest,          // FailedPrecondition
+	10: http.StatusConflict,            // Aborted
+	11: http.StatusBadRequest,          // OutOfRange
+	12: http.StatusNotImplemented,      // Unimplemented
+	13: http.StatusInternalServerError, // Internal
+	14: http.StatusServiceUnavailable,  // Unavailable
+	15: http.StatusInternalServerError, // DataLoss
+	16: http.StatusUnauthorized,        // Unauthenticated
+}
+
+type grpcMapping struct {
+	status int
+	title  string
+}
+
+// GRPCProblem builds the problem for a gRPC error, e.g. from a grpc-gateway
+// error handler:
+//
+//	s := status.Convert(err)
+//	rfc.WriteProblem(w, r, rfc.GRPCProblem(uint32(s.Code()), s.Message()))
+//
+// The status comes from WithGRPCCode overrides, then GRPCStatus, then 500.
+func (rfc7807 *RFC7807) GRPCProblem(code uint32, message string, extensions ...*Extension) *Problem {
+	mapping, ok := rfc7807.grpcCodes[code]
+	if !ok {
+		mapping.status, ok = GRPCStatus[code]
+		if !ok {
+			mapping.status = http.StatusInternalServerError
+		}
+	}
+
+	doc := rfc7807.lookup(mapping.title)
+	return rfc7807.problem(nil, doc, mapping.title, mapping.status, message, rfc7807.allowedExtensions(doc, extensions))
+}
@@ -0,0 +1,33 @@
+package rfc7807
+
+import "testing"
+
+func TestGRPCProblem(t *testing.T) {
+	problems := New("http://example.com/errors", WithGRPCCode(5, 410, "Order Gone"), WithGRPCCode(8, 503, ""))
+	if _, err := problems.Doc("Order Gone", "The order was archived."); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		code   uint32
+		typ    string
+		title  string
+		status int
+	}{
+		{"default mapping", 3, "", "Bad Request", 400},
+		{"client closed", 1, "", "", 499},
+		{"unknown code", 42, "", "Internal Server Error", 500},
+		{"override with doc", 5, "http://example.com/errors/Order%20Gone.html", "Order Gone", 410},
+		{"override without title", 8, "", "Service Unavailable", 503},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problem := problems.GRPCProblem(test.code, "bad id")
+			if problem.Type != test.typ || problem.Title != test.title || problem.Status != test.status || problem.Detail != "bad id" {
+				t.Errorf("GRPCProblem(%d) = %+v, want %q %q %d", test.code, problem, test.typ, test.title, test.status)
+			}
+		})
+	}
+}
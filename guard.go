@@ -0,0 +1,47 @@
+package rfc7807
+
+import (
+	"net/http"
+)
+
+// Wrap returns w tracking whether its response was committed. Problems written
+// through it once the header is sent are dropped with a logged warning instead
+// of producing a superfluous WriteHeader and a body appended to the one already
+// written, so Error can be called defensively, e.g. from a deferred cleanup.
+func (rfc7807 *RFC7807) Wrap(w http.ResponseWriter) http.ResponseWriter {
+	if _, ok := w.(*guardWriter); ok {
+		return w
+	}
+
+	return &guardWriter{ResponseWriter: w}
+}
+
+type guardWriter struct {
+	http.ResponseWriter
+	committed bool
+}
+
+func (w *guardWriter) WriteHeader(status int) {
+	// 1xx responses are informational and leave the response open.
+	if status >= 200 {
+		w.committed = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *guardWriter) Write(b []byte) (int, error) {
+	w.committed = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *guardWriter) Flush() {
+	w.committed = true
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *guardWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,60 @@
+package rfc7807
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	var logs bytes.Buffer
+	problems := New("http://example.com/errors", WithLogger(log.New(&logs, "", 0)))
+
+	tests := []struct {
+		name    string
+		before  func(w http.ResponseWriter)
+		status  int
+		body    string
+		dropped bool
+	}{
+		{"nothing written", func(w http.ResponseWriter) {}, 404, "", false},
+		// The recorder keeps the first status, while a server sends both.
+		{"informational", func(w http.ResponseWriter) { w.WriteHeader(http.StatusEarlyHints) }, http.StatusEarlyHints, "", false},
+		{"header written", func(w http.ResponseWriter) { w.WriteHeader(http.StatusOK) }, 200, "", true},
+		{"body written", func(w http.ResponseWriter) { w.Write([]byte(`{"ok":true}`)) }, 200, `{"ok":true}`, true},
+		{"flushed", func(w http.ResponseWriter) { w.(http.Flusher).Flush() }, 200, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logs.Reset()
+			recorder := httptest.NewRecorder()
+			w := problems.Wrap(recorder)
+			test.before(w)
+
+			got := problems.Error(w, "", 404, "no user")
+			if test.dropped {
+				if got != 0 || recorder.Body.String() != test.body || !strings.Contains(logs.String(), "already committed") {
+					t.Errorf("Error() = %d, body %q, logged %q, want the problem dropped with a warning", got, recorder.Body.String(), logs.String())
+				}
+			} else if got != 404 || decode(t, recorder)["status"] != float64(404) || logs.Len() != 0 {
+				t.Errorf("Error() = %d, body %q, logged %q, want the problem", got, recorder.Body.String(), logs.String())
+			}
+			if recorder.Code != test.status {
+				t.Errorf("status = %d, want %d", recorder.Code, test.status)
+			}
+		})
+	}
+
+	recorder := httptest.NewRecorder()
+	w := problems.Wrap(recorder)
+	if problems.Wrap(w) != w {
+		t.Error("Wrap() wrapped a wrapped writer again")
+	}
+	if err := http.NewResponseController(w).Flush(); err != nil || !recorder.Flushed {
+		t.Errorf("ResponseController.Flush() = %v, flushed %v, want the recorder flushed", err, recorder.Flushed)
+	}
+}
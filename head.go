@@ -0,0 +1,42 @@
+package rfc7807
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// headWriter answers a HEAD request: it counts the body instead of writing it,
+// then sends the header with the Content-Length of the body a GET would get.
+type headWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+func (w *headWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *headWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.length += len(b)
+	return len(b), nil
+}
+
+// Flush is a no-op: nothing can be sent before the length is known.
+func (w *headWriter) Flush() {}
+
+func (w *headWriter) finish() {
+	if w.status == 0 {
+		return
+	}
+
+	if w.length > 0 {
+		w.Header().Set("Content-Length", strconv.Itoa(w.length))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
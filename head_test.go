@@ -0,0 +1,51 @@
+package rfc7807
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestHeadError(t *testing.T) {
+	problems := New("http://example.com/errors", WithIncrementalFlush())
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		accept string
+		title  string
+		status int
+	}{
+		{"JSON", "application/json", "NotFound", 404},
+		{"HTML", "text/html", "NotFound", 404},
+		{"status only", "", "", 503},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := func(method string) *httptest.ResponseRecorder {
+				r := httptest.NewRequest(method, "/users/1", nil)
+				r.Header.Set("Accept", test.accept)
+				w := httptest.NewRecorder()
+				if got := problems.ErrorRequest(w, r, test.title, test.status, "no such user"); got != test.status {
+					t.Errorf("%s: ErrorRequest() = %d, want %d", method, got, test.status)
+				}
+				return w
+			}
+			get, head := request(http.MethodGet), request(http.MethodHead)
+
+			if head.Code != test.status || head.Body.Len() != 0 || head.Flushed {
+				t.Errorf("HEAD = %d with %d body bytes, flushed %v, want %d without a body", head.Code, head.Body.Len(), head.Flushed, test.status)
+			}
+			if got, want := head.Header().Get("Content-Type"), get.Header().Get("Content-Type"); got != want || got == "" {
+				t.Errorf("HEAD Content-Type = %q, want %q", got, want)
+			}
+			if got, want := head.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+				t.Errorf("HEAD Content-Length = %q, want %s, the length of the GET body", got, want)
+			}
+		})
+	}
+}
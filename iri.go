@@ -0,0 +1,46 @@
+package rfc7807
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// toIRI decodes the percent-encoded UTF-8 sequences of non-ASCII characters in
+// uri, as described in RFC 3987 section 3.2. Escapes of ASCII characters, and
+// sequences that are not valid UTF-8, are kept as they are.
+func toIRI(uri string) string {
+	if !strings.Contains(uri, "%") {
+		return uri
+	}
+
+	var buf strings.Builder
+	buf.Grow(len(uri))
+
+	for i := 0; i < len(uri); {
+		start := i
+		octets := []byte{}
+		for i+2 < len(uri) && uri[i] == '%' {
+			b, err := strconv.ParseUint(uri[i+1:i+3], 16, 8)
+			if err != nil || b < 0x80 {
+				break
+			}
+			octets = append(octets, byte(b))
+			i += 3
+		}
+
+		if len(octets) == 0 {
+			buf.WriteByte(uri[i])
+			i++
+			continue
+		}
+
+		if utf8.Valid(octets) {
+			buf.Write(octets)
+		} else {
+			buf.WriteString(uri[start:i])
+		}
+	}
+
+	return buf.String()
+}
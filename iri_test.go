@@ -0,0 +1,57 @@
+package rfc7807
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithIRITypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		want    string
+	}{
+		{"URI", nil, "http://example.com/errors/%E8%A6%8B%E3%81%A4%E3%81%8B%E3%82%8A%E3%81%BE%E3%81%9B%E3%82%93.html"},
+		{"IRI", []Option{WithIRITypes()}, "http://example.com/errors/見つかりません.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", test.options...)
+			if _, err := problems.Doc("見つかりません", "missing"); err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			problems.Error(w, "見つかりません", 404, "")
+			if got := decode(t, w)["type"]; got != test.want {
+				t.Errorf("type = %q, want %q", got, test.want)
+			}
+			if w := serve(problems, http.MethodGet, strings.TrimPrefix(test.want, "http://example.com/errors"), nil); w.Code != http.StatusOK {
+				t.Errorf("GET %s = %d, want 200", test.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestToIRI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"/%E6%97%A5%E6%9C%AC.html", "/日本.html"},
+		{"/a%20b%2Fc.html", "/a%20b%2Fc.html"},
+		{"/%E6%97%A5%20%E6%9C%AC", "/日%20本"},
+		{"/%E6%97.html", "/%E6%97.html"},
+		{"/%ff%fe", "/%ff%fe"},
+		{"/plain", "/plain"},
+	}
+
+	for _, test := range tests {
+		if got := toIRI(test.uri); got != test.want {
+			t.Errorf("toIRI(%q) = %q, want %q", test.uri, got, test.want)
+		}
+	}
+}
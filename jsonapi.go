@@ -0,0 +1,96 @@
+package rfc7807
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ErrorFormat is the document format problems are written in.
+type ErrorFormat int
+
+const (
+	// FormatRFC7807 writes problems as application/problem+json, the default.
+	FormatRFC7807 ErrorFormat = iota
+	// FormatJSONAPI writes problems as JSON:API error documents.
+	FormatJSONAPI
+)
+
+// WithErrorFormat writes problems in format instead of RFC 7807.
+func WithErrorFormat(format ErrorFormat) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.errorFormat = format
+	}
+}
+
+// jsonAPIDocument maps problem to a JSON:API error document. A problem with an
+// "errors" extension of violations, as written by Unprocessable, gives one error
+// object per violation, pointing at its field. Type and instance become the
+// "type" and "about" links, and other extensions go into meta.
+func jsonAPIDocument(problem *Problem) ([]byte, error) {
+	violations, _ := extensionValue(problem.Extensions, "errors").([]Violation)
+
+	meta := make(extensionObject, 0, len(problem.Extensions))
+	for _, extension := range problem.Extensions {
+		if reservedMembers[extension.Key] || (violations != nil && extension.Key == "errors") {
+			continue
+		}
+		meta = append(meta, extension)
+	}
+
+	links := extensionObject{}
+	if problem.Instance != "" {
+		links = append(links, Ext("about", problem.Instance))
+	}
+	if problem.Type != "" {
+		links = append(links, Ext("type", problem.Type))
+	}
+
+	errorObject := func(detail string, pointer string) extensionObject {
+		object := extensionObject{
+			Ext("status", strconv.Itoa(problem.Status)),
+			Ext("title", problem.Title),
+			Ext("detail", detail),
+		}
+		if pointer != "" {
+			object = append(object, Ext("source", extensionObject{Ext("pointer", pointer)}))
+		}
+		if len(links) > 0 {
+			object = append(object, Ext("links", links))
+		}
+		if len(meta) > 0 {
+			object = append(object, Ext("meta", meta))
+		}
+		return object
+	}
+
+	errors := []extensionObject{}
+	for _, violation := range violations {
+		errors = append(errors, errorObject(violation.Message, jsonAPIPointer(violation.Field)))
+	}
+	if len(violations) == 0 {
+		errors = append(errors, errorObject(problem.Detail, ""))
+	}
+
+	return extensionObject{Ext("errors", errors)}.MarshalJSON()
+}
+
+// jsonAPIPointer turns a field name like "address.city" into a JSON pointer
+// into the attributes of the request document. Pointers are kept as they are.
+func jsonAPIPointer(field string) string {
+	if strings.HasPrefix(field, "/") {
+		return field
+	}
+
+	return "/data/attributes/" + strings.Replace(field, ".", "/", -1)
+}
+
+// extensionValue returns the value of the last extension named key, or nil.
+func extensionValue(extensions []*Extension, key string) interface{} {
+	for i := len(extensions) - 1; i >= 0; i-- {
+		if extensions[i].Key == key {
+			return extensions[i].Value
+		}
+	}
+
+	return nil
+}
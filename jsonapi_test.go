@@ -0,0 +1,86 @@
+package rfc7807
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWithErrorFormatJSONAPI(t *testing.T) {
+	problems := New("http://example.com/errors", WithErrorFormat(FormatJSONAPI))
+	if _, err := problems.Doc("Out of Credit", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		write func(w *httptest.ResponseRecorder)
+		want  []interface{}
+	}{
+		{
+			name: "violations",
+			write: func(w *httptest.ResponseRecorder) {
+				problems.Unprocessable(w, Violation{Field: "name", Message: "is required"}, Violation{Field: "address.city", Message: "is unknown"}, Violation{Field: "/data/relationships/owner", Message: "is missing"})
+			},
+			want: []interface{}{
+				map[string]interface{}{"status": "422", "title": "Unprocessable Entity", "detail": "is required", "source": map[string]interface{}{"pointer": "/data/attributes/name"}},
+				map[string]interface{}{"status": "422", "title": "Unprocessable Entity", "detail": "is unknown", "source": map[string]interface{}{"pointer": "/data/attributes/address/city"}},
+				map[string]interface{}{"status": "422", "title": "Unprocessable Entity", "detail": "is missing", "source": map[string]interface{}{"pointer": "/data/relationships/owner"}},
+			},
+		},
+		{
+			name: "problem",
+			write: func(w *httptest.ResponseRecorder) {
+				problems.Error(w, "Out of Credit", 403, "balance too low", Instance("/account/1"), Ext("balance", 30))
+			},
+			want: []interface{}{
+				map[string]interface{}{
+					"status": "403", "title": "Out of Credit", "detail": "balance too low",
+					"links": map[string]interface{}{"about": "/account/1", "type": "http://example.com/errors/Out%20of%20Credit.html"},
+					"meta":  map[string]interface{}{"balance": float64(30)},
+				},
+			},
+		},
+		{
+			name: "status only",
+			write: func(w *httptest.ResponseRecorder) {
+				problems.Error(w, "", 404, "")
+			},
+			want: []interface{}{
+				map[string]interface{}{"status": "404", "title": "Not Found", "detail": ""},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			test.write(w)
+
+			if got := w.Header().Get("Content-Type"); got != "application/vnd.api+json" {
+				t.Errorf("Content-Type = %q, want JSON:API", got)
+			}
+			document := decode(t, w)
+			if len(document) != 1 || !reflect.DeepEqual(document["errors"], test.want) {
+				t.Errorf("document = %v, want errors %v", document, test.want)
+			}
+		})
+	}
+}
+
+func TestJSONAPIPointer(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{"name", "/data/attributes/name"},
+		{"address.city", "/data/attributes/address/city"},
+		{"/data/relationships/owner", "/data/relationships/owner"},
+	}
+
+	for _, test := range tests {
+		if got := jsonAPIPointer(test.field); got != test.want {
+			t.Errorf("jsonAPIPointer(%q) = %q, want %q", test.field, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package rfc7807
+
+import (
+	"strings"
+	"unicode"
+)
+
+// KeyCase is the case extension member names are written in.
+type KeyCase int
+
+const (
+	// CaseAsIs writes extension member names as they are given, the default.
+	CaseAsIs KeyCase = iota
+	// CaseCamel writes extension member names in camelCase, e.g. "userId".
+	CaseCamel
+	// CaseSnake writes extension member names in snake_case, e.g. "user_id".
+	CaseSnake
+)
+
+// WithKeyCase converts extension member names, nested ones included, to
+// keyCase. Standard members are left as the RFC names them.
+func WithKeyCase(keyCase KeyCase) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.keyCase = keyCase
+	}
+}
+
+// cased returns a copy of extensions with their keys converted to keyCase.
+func (extensions extensionObject) cased(keyCase KeyCase) extensionObject {
+	convert := camelCase
+	if keyCase == CaseSnake {
+		convert = snakeCase
+	}
+
+	cased := make(extensionObject, len(extensions))
+	for i, extension := range extensions {
+		value := extension.Value
+		if nested, ok := value.(extensionObject); ok {
+			value = nested.cased(keyCase)
+		}
+		cased[i] = Ext(convert(extension.Key), value)
+	}
+
+	return cased
+}
+
+// camelCase turns "user_id" or "user-id" into "userId".
+func camelCase(key string) string {
+	var buf strings.Builder
+	upper := false
+	for _, r := range key {
+		if r == '_' || r == '-' {
+			upper = buf.Len() > 0
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
+
+// snakeCase turns "userId" into "user_id" and "HTTPStatus" into "http_status".
+func snakeCase(key string) string {
+	runes := []rune(key)
+
+	var buf strings.Builder
+	for i, r := range runes {
+		if r == '-' {
+			r = '_'
+		}
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' && runes[i-1] != '-' &&
+				(!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				buf.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,79 @@
+package rfc7807
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"user_id":    "userId",
+		"user-id":    "userId",
+		"userId":     "userId",
+		"_private":   "private",
+		"retry__at_": "retryAt",
+		"balance":    "balance",
+		"":           "",
+	}
+
+	for key, want := range tests {
+		if got := camelCase(key); got != want {
+			t.Errorf("camelCase(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"userId":     "user_id",
+		"HTTPStatus": "http_status",
+		"requestID":  "request_id",
+		"user-id":    "user_id",
+		"user_id":    "user_id",
+		"Balance":    "balance",
+		"":           "",
+	}
+
+	for key, want := range tests {
+		if got := snakeCase(key); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestWithKeyCase(t *testing.T) {
+	extensions := func() []*Extension {
+		return []*Extension{
+			Ext("user_id", 1),
+			Ext("requestId", "abc"),
+			Ext("rate_limit", extensionObject{Ext("retryAfter", 3), Ext("max_requests", 10)}),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options []Option
+		want    string
+	}{
+		{"default", nil, `"user_id":1,"requestId":"abc","rate_limit":{"retryAfter":3,"max_requests":10}}`},
+		{"CaseAsIs", []Option{WithKeyCase(CaseAsIs)}, `"user_id":1,"requestId":"abc","rate_limit":{"retryAfter":3,"max_requests":10}}`},
+		{"CaseCamel", []Option{WithKeyCase(CaseCamel)}, `"userId":1,"requestId":"abc","rateLimit":{"retryAfter":3,"maxRequests":10}}`},
+		{"CaseSnake", []Option{WithKeyCase(CaseSnake)}, `"user_id":1,"request_id":"abc","rate_limit":{"retry_after":3,"max_requests":10}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", append(test.options, WithPrettyQueryParam("pretty"))...)
+			if _, err := problems.Doc("OutOfCredit", ""); err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			problems.Error(w, "OutOfCredit", 403, "low", append(extensions(), Instance("/account/1"))...)
+			want := `{"type":"http://example.com/errors/OutOfCredit.html","title":"OutOfCredit","status":403,"detail":"low","instance":"/account/1",` + test.want + "\n"
+			if w.Body.String() != want {
+				t.Errorf("got  %s\nwant %s", w.Body.String(), want)
+			}
+		})
+	}
+}
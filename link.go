@@ -0,0 +1,36 @@
+package rfc7807
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Link is an RFC 8288 web link carried in the "links" extension.
+type Link struct {
+	Rel   string `json:"rel"`
+	Href  string `json:"href"`
+	Title string `json:"title,omitempty"`
+}
+
+type Links []Link
+
+// ExtLinks returns the "links" extension holding links.
+func ExtLinks(links ...Link) *Extension {
+	return Ext("links", Links(links))
+}
+
+// setLinkHeader mirrors the describedby links of extensions into the Link header.
+func setLinkHeader(w http.ResponseWriter, extensions []*Extension) {
+	for _, extension := range extensions {
+		links, ok := extension.Value.(Links)
+		if !ok || extension.Key != "links" {
+			continue
+		}
+
+		for _, link := range links {
+			if link.Rel == "describedby" {
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="describedby"`, link.Href))
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package rfc7807
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExtLinks(t *testing.T) {
+	links := ExtLinks(
+		Link{Rel: "help", Href: "https://example.com/help"},
+		Link{Rel: "describedby", Href: "https://example.com/schema", Title: "Schema"},
+	)
+
+	tests := []struct {
+		name    string
+		options []Option
+		header  []string
+	}{
+		{"member only", nil, nil},
+		{"with header", []Option{WithLinkHeader()}, []string{`<https://example.com/schema>; rel="describedby"`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", test.options...)
+			w := httptest.NewRecorder()
+			problems.Error(w, "Gone", 410, "", links)
+
+			want := []interface{}{
+				map[string]interface{}{"rel": "help", "href": "https://example.com/help"},
+				map[string]interface{}{"rel": "describedby", "href": "https://example.com/schema", "title": "Schema"},
+			}
+			if got := decode(t, w)["links"]; !reflect.DeepEqual(got, want) {
+				t.Errorf("links = %v, want %v", got, want)
+			}
+			if got := w.Header()["Link"]; !reflect.DeepEqual(got, test.header) {
+				t.Errorf("Link = %q, want %q", got, test.header)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package rfc7807
+
+import "net/http"
+
+// Marshaler encodes problems in a format other than JSON, e.g. CBOR for
+// constrained clients, keeping its library out of this package's dependencies.
+type Marshaler interface {
+	// ContentType is the Content-Type of the encoded problem, e.g.
+	// "application/problem+cbor".
+	ContentType() string
+	// Marshal encodes the members of a problem, as returned by Problem.Map.
+	Marshal(members map[string]interface{}) ([]byte, error)
+}
+
+// WithMarshaler encodes problems with marshaler for requests that accept
+// mediaType (e.g. "application/cbor") ahead of JSON. JSON stays the default.
+func WithMarshaler(mediaType string, marshaler Marshaler) Option {
+	return func(rfc7807 *RFC7807) {
+		if rfc7807.marshalers == nil {
+			rfc7807.marshalers = map[string]Marshaler{}
+		}
+		rfc7807.marshalers[mediaType] = marshaler
+	}
+}
+
+// marshaler returns the WithMarshaler marshaler r accepts best, or nil.
+func (rfc7807 *RFC7807) marshaler(r *http.Request) Marshaler {
+	if r == nil || len(rfc7807.marshalers) == 0 {
+		return nil
+	}
+
+	return rfc7807.marshalers[rfc7807.negotiate(r)]
+}
@@ -0,0 +1,273 @@
+package rfc7807
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// cborMarshaler is a minimal CBOR (RFC 8949) codec for the values problems are
+// made of, standing in for a real CBOR library.
+type cborMarshaler struct{}
+
+func (cborMarshaler) ContentType() string {
+	return "application/problem+cbor"
+}
+
+func (cborMarshaler) Marshal(members map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := cborEncode(&buf, members); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func cborHead(buf *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		buf.WriteByte(major<<5 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(major<<5 | 24)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(major<<5 | 25)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major<<5 | 26)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(major<<5 | 27)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+func cborEncode(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if v {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case int:
+		if v < 0 {
+			cborHead(buf, 1, uint64(-1-v))
+		} else {
+			cborHead(buf, 0, uint64(v))
+		}
+	case float64:
+		buf.WriteByte(0xfb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v))
+	case string:
+		cborHead(buf, 3, uint64(len(v)))
+		buf.WriteString(v)
+	case []string:
+		cborHead(buf, 4, uint64(len(v)))
+		for _, s := range v {
+			cborEncode(buf, s)
+		}
+	case []interface{}:
+		cborHead(buf, 4, uint64(len(v)))
+		for _, e := range v {
+			if err := cborEncode(buf, e); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		cborHead(buf, 5, uint64(len(v)))
+		for _, key := range keys {
+			cborEncode(buf, key)
+			if err := cborEncode(buf, v[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cbor: unsupported type %T", v)
+	}
+
+	return nil
+}
+
+func cborDecode(r *bytes.Reader) (interface{}, error) {
+	initial, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	major, info := initial>>5, initial&0x1f
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		case 27:
+			var bits uint64
+			err := binary.Read(r, binary.BigEndian, &bits)
+			return math.Float64frombits(bits), err
+		}
+		return nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+	}
+
+	n := uint64(info)
+	switch info {
+	case 24:
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		n = uint64(b)
+	case 25:
+		var u uint16
+		err = binary.Read(r, binary.BigEndian, &u)
+		n = uint64(u)
+	case 26:
+		var u uint32
+		err = binary.Read(r, binary.BigEndian, &u)
+		n = uint64(u)
+	case 27:
+		err = binary.Read(r, binary.BigEndian, &n)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0:
+		return int(n), nil
+	case 1:
+		return -1 - int(n), nil
+	case 3:
+		s := make([]byte, n)
+		_, err := r.Read(s)
+		return string(s), err
+	case 4:
+		a := make([]interface{}, n)
+		for i := range a {
+			if a[i], err = cborDecode(r); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	case 5:
+		m := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			key, err := cborDecode(r)
+			if err != nil {
+				return nil, err
+			}
+			s, ok := key.(string)
+			if !ok {
+				return nil, errors.New("cbor: non-string map key")
+			}
+			if m[s], err = cborDecode(r); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("cbor: unsupported major type %d", major)
+}
+
+func TestWithMarshaler(t *testing.T) {
+	problems := New("http://example.com/errors", WithMarshaler("application/cbor", cborMarshaler{}))
+	if _, err := problems.Doc("OutOfCredit", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/cbor")
+	w := httptest.NewRecorder()
+	problems.ErrorRequest(w, r, "OutOfCredit", 403, "low", Ext("balance", -30), Ext("ratio", 0.5), Ext("accounts", []string{"/account/1"}), Ext("frozen", false), Ext("note", nil))
+
+	if got := w.Header().Get("Content-Type"); got != "application/problem+cbor" {
+		t.Fatalf("Content-Type = %q, want CBOR", got)
+	}
+	decoded, err := cborDecode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"type": "http://example.com/errors/OutOfCredit.html", "title": "OutOfCredit", "status": 403, "detail": "low",
+		"balance": -30, "ratio": 0.5, "accounts": []interface{}{"/account/1"}, "frozen": false, "note": nil,
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("decoded problem = %v, want %v", decoded, want)
+	}
+}
+
+func TestWithMarshalerNegotiation(t *testing.T) {
+	problems := New("http://example.com/errors", WithMarshaler("application/cbor", cborMarshaler{}), WithLogger(log.New(ioutil.Discard, "", 0)))
+
+	tests := []struct {
+		accept      string
+		extension   *Extension
+		contentType string
+	}{
+		{"", Ext("key", "value"), "application/problem+json; charset=utf-8"},
+		{"application/json, application/cbor;q=0.5", Ext("key", "value"), "application/problem+json; charset=utf-8"},
+		{"application/cbor", Ext("key", "value"), "application/problem+cbor"},
+		{"application/cbor", Ext("key", struct{}{}), "application/problem+json; charset=utf-8"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		problems.ErrorRequest(w, r, "", 400, "", test.extension)
+
+		if got := w.Header().Get("Content-Type"); got != test.contentType {
+			t.Errorf("Accept %q with %T: Content-Type = %q, want %q", test.accept, test.extension.Value, got, test.contentType)
+		}
+	}
+}
+
+func TestWithMarshalerNested(t *testing.T) {
+	problems := New("http://example.com/errors", WithMarshaler("application/cbor", cborMarshaler{}))
+	notFound := &Problem{Title: "Not Found", Status: 404, Extensions: []*Extension{Ext("id", 7)}}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/cbor")
+	w := httptest.NewRecorder()
+	problems.ErrorRequest(w, r, "Multi-Status", 207, "", Ext("errors", []extensionObject{{Ext("index", 1), Ext("problem", notFound)}}), Ext("causes", []interface{}{notFound, []*Problem{notFound}}))
+
+	if got := w.Header().Get("Content-Type"); got != "application/problem+cbor" {
+		t.Fatalf("Content-Type = %q, want CBOR", got)
+	}
+	decoded, err := cborDecode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problem := map[string]interface{}{"title": "Not Found", "status": 404, "detail": "", "id": 7}
+	want := map[string]interface{}{
+		"title": "Multi-Status", "status": 207, "detail": "",
+		"errors": []interface{}{map[string]interface{}{"index": 1, "problem": problem}},
+		"causes": []interface{}{problem, []interface{}{problem}},
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("decoded problem = %v, want %v", decoded, want)
+	}
+}
@@ -0,0 +1,134 @@
+package rfc7807
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// DefaultProblems is a middleware that writes a default problem for the status
+// of any 4xx/5xx response the next handler leaves without a body.
+//
+// Nothing but the status code is held back: the header of an error response is
+// delayed until its first non-empty Write (or Flush), so there is no body
+// buffering and no size to cap.
+func (rfc7807 *RFC7807) DefaultProblems(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		writer := &statusWriter{ResponseWriter: aWriter}
+		next.ServeHTTP(writer, aRequest)
+
+		if writer.pending {
+			writer.pending = false
+			// The header describes the body the handler did not write, e.g. a
+			// "Content-Length: 0" that would truncate the problem.
+			aWriter.Header().Del("Content-Length")
+			aWriter.Header().Del("Content-Type")
+			rfc7807.ErrorRequest(aWriter, aRequest, "", writer.status, "")
+		}
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	pending     bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+
+	if status >= 400 && !strings.HasPrefix(w.Header().Get("Content-Type"), "application/problem+") {
+		w.pending = true
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.pending {
+		if len(b) == 0 {
+			return 0, nil
+		}
+		w.commit()
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if w.pending {
+		w.commit()
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *statusWriter) commit() {
+	w.pending = false
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Recoverer is a middleware that recovers from panics in the next handler and
+// writes a 500 problem instead. With WithDebug, the panic value becomes the
+// detail and the stack trace is attached as a "stack" extension.
+func (rfc7807 *RFC7807) Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			if !rfc7807.debug {
+				rfc7807.ErrorRequest(aWriter, aRequest, "", http.StatusInternalServerError, "")
+				return
+			}
+
+			stack := Ext("stack", stackFrames(debug.Stack()))
+			rfc7807.ErrorRequest(aWriter, aRequest, "", http.StatusInternalServerError, fmt.Sprint(rec), stack)
+		}()
+
+		next.ServeHTTP(aWriter, aRequest)
+	})
+}
+
+// stackFrames splits a runtime/debug.Stack trace into one "function (file:line)"
+// entry per frame, dropping the goroutine header.
+func stackFrames(stack []byte) []string {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "goroutine ") {
+		lines = lines[1:]
+	}
+
+	frames := make([]string, 0, len(lines)/2)
+	for i := 0; i < len(lines); i += 2 {
+		frame := strings.TrimSpace(lines[i])
+		if i+1 < len(lines) {
+			frame += " (" + strings.TrimSpace(lines[i+1]) + ")"
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
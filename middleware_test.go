@@ -0,0 +1,162 @@
+package rfc7807
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultProblems(t *testing.T) {
+	problems := New("http://example.com/errors")
+
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			name: "status only",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			status:      http.StatusNotFound,
+			contentType: "application/problem+json; charset=utf-8",
+		},
+		{
+			name: "status only with empty length",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.Header().Set("Content-Length", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			status:      http.StatusServiceUnavailable,
+			contentType: "application/problem+json; charset=utf-8",
+		},
+		{
+			name: "error with body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("bad"))
+			},
+			status:      http.StatusBadRequest,
+			contentType: "text/plain",
+			body:        "bad",
+		},
+		{
+			name: "success",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			status: http.StatusNoContent,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := serve(problems.DefaultProblems(test.handler), http.MethodGet, "/", nil)
+
+			if w.Code != test.status {
+				t.Errorf("status = %d, want %d", w.Code, test.status)
+			}
+			if got := w.Header().Get("Content-Type"); got != test.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, test.contentType)
+			}
+			if _, ok := w.Header()["Content-Length"]; ok {
+				t.Errorf("Content-Length = %q kept", w.Header().Get("Content-Length"))
+			}
+
+			if test.contentType != "application/problem+json; charset=utf-8" {
+				if w.Body.String() != test.body {
+					t.Errorf("body = %q, want %q", w.Body.String(), test.body)
+				}
+				return
+			}
+			problem := decode(t, w)
+			if problem["status"] != float64(test.status) || problem["title"] != http.StatusText(test.status) {
+				t.Errorf("problem = %v, want the default problem for %d", problem, test.status)
+			}
+		})
+	}
+}
+
+// deadlineRecorder is a recorder supporting write deadlines, like the writer of
+// net/http does, to see if http.ResponseController reaches it.
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	deadline time.Time
+}
+
+func (w *deadlineRecorder) SetWriteDeadline(deadline time.Time) error {
+	w.deadline = deadline
+	return nil
+}
+
+func TestDefaultProblemsUnwrap(t *testing.T) {
+	problems := New("http://example.com/errors")
+	deadline := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	handler := problems.DefaultProblems(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+			t.Errorf("SetWriteDeadline() = %v", err)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	w := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !w.deadline.Equal(deadline) || w.Code != http.StatusNotFound {
+		t.Errorf("deadline = %v, status = %d, want the deadline set and the 404 problem", w.deadline, w.Code)
+	}
+}
+
+func TestRecoverer(t *testing.T) {
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	t.Run("debug", func(t *testing.T) {
+		problems := New("http://example.com/errors", WithDebug())
+		w := serve(problems.Recoverer(panicking), http.MethodGet, "/", nil)
+
+		problem := decode(t, w)
+		if w.Code != http.StatusInternalServerError || problem["detail"] != "boom" {
+			t.Errorf("problem = %d %v, want 500 with the panic as detail", w.Code, problem)
+		}
+		stack, _ := problem["stack"].([]interface{})
+		if len(stack) == 0 {
+			t.Fatalf("stack = %v, want frames", problem["stack"])
+		}
+		for _, frame := range stack {
+			if s, ok := frame.(string); !ok || s == "" || strings.HasPrefix(s, "goroutine ") {
+				t.Errorf("invalid frame %q", frame)
+			}
+		}
+	})
+
+	t.Run("production", func(t *testing.T) {
+		problems := New("http://example.com/errors")
+		w := serve(problems.Recoverer(panicking), http.MethodGet, "/", nil)
+
+		problem := decode(t, w)
+		if w.Code != http.StatusInternalServerError || problem["detail"] != "" {
+			t.Errorf("problem = %d %v, want 500 without detail", w.Code, problem)
+		}
+		if _, ok := problem["stack"]; ok {
+			t.Error("stack written outside of debug mode")
+		}
+	})
+}
+
+func TestStackFrames(t *testing.T) {
+	stack := "goroutine 1 [running]:\nmain.f(...)\n\t/src/main.go:10 +0x1d\nmain.main()\n\t/src/main.go:5 +0x25\n"
+	want := []string{"main.f(...) (/src/main.go:10 +0x1d)", "main.main() (/src/main.go:5 +0x25)"}
+
+	if got := stackFrames([]byte(stack)); !reflect.DeepEqual(got, want) {
+		t.Errorf("stackFrames() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,170 @@
+package rfc7807
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonTypes and htmlTypes are the media types problems are offered in, besides
+// those of WithMarshaler.
+var (
+	jsonTypes = []string{"application/problem+json", "application/json"}
+	htmlTypes = []string{"text/html", "application/xhtml+xml"}
+)
+
+// negotiate returns the media type r accepts best among JSON, HTML and the
+// WithMarshaler types, or "" for JSON when r has no preference.
+func (rfc7807 *RFC7807) negotiate(r *http.Request) string {
+	offers := make([]string, 0, len(jsonTypes)+len(htmlTypes)+len(rfc7807.marshalers))
+	offers = append(offers, jsonTypes...)
+	offers = append(offers, htmlTypes...)
+	for mediaType := range rfc7807.marshalers {
+		offers = append(offers, mediaType)
+	}
+	sort.Strings(offers[len(jsonTypes)+len(htmlTypes):])
+
+	best := bestOffer(r.Header.Get("Accept"), offers)
+	for _, mediaType := range jsonTypes {
+		if best == mediaType {
+			return ""
+		}
+	}
+
+	return best
+}
+
+// acceptsHTML reports whether the client prefers HTML over JSON and the
+// WithMarshaler types, as browsers do.
+func (rfc7807 *RFC7807) acceptsHTML(r *http.Request) bool {
+	best := rfc7807.negotiate(r)
+	for _, mediaType := range htmlTypes {
+		if best == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bestOffer returns the offer with the highest quality in the Accept header
+// accept, each offer taking the q of the most specific media range matching it.
+// Ties go to the offer whose range comes first in accept, then to the earlier
+// offer. Offers with q=0 are not acceptable; "" means none is.
+func bestOffer(accept string, offers []string) string {
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+
+	ranges := []mediaRange{}
+	for _, element := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(element))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+
+	best, bestQ, bestIndex := "", 0.0, len(ranges)
+	for _, offer := range offers {
+		q, index, specificity := 0.0, len(ranges), -1
+		for i, mediaRange := range ranges {
+			s := matchSpecificity(mediaRange.mediaType, offer)
+			if s > specificity {
+				q, index, specificity = mediaRange.q, i, s
+			}
+		}
+
+		if q > bestQ || (q == bestQ && q > 0 && index < bestIndex) {
+			best, bestQ, bestIndex = offer, q, index
+		}
+	}
+
+	return best
+}
+
+// matchSpecificity returns how specifically mediaRange matches mediaType: 2 for
+// the same type, 1 for "type/*", 0 for "*/*", or -1 for no match.
+func matchSpecificity(mediaRange string, mediaType string) int {
+	switch {
+	case mediaRange == mediaType:
+		return 2
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	case mediaRange == "*/*":
+		return 0
+	}
+
+	return -1
+}
+
+// acceptsGzip reports whether the Accept-Encoding header of r allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, err := mime.ParseMediaType(strings.TrimSpace(coding))
+		if err != nil || (name != "gzip" && name != "x-gzip") {
+			continue
+		}
+
+		return acceptable(params)
+	}
+
+	return false
+}
+
+// vary adds field to the Vary header of w unless it is already listed.
+func vary(w http.ResponseWriter, field string) {
+	for _, value := range w.Header()["Vary"] {
+		for _, listed := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(listed), field) {
+				return
+			}
+		}
+	}
+
+	w.Header().Add("Vary", field)
+}
+
+// language returns the first language of the Accept-Language header of r that
+// locales has, matching "ja-JP" to "ja" when needed, or "" for none.
+func language(r *http.Request, locales map[string]*doc) string {
+	for _, languageRange := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, err := mime.ParseMediaType(strings.TrimSpace(languageRange))
+		if err != nil || !acceptable(params) {
+			continue
+		}
+
+		if _, ok := locales[tag]; ok {
+			return tag
+		}
+		if i := strings.IndexByte(tag, '-'); i >= 0 {
+			if _, ok := locales[tag[:i]]; ok {
+				return tag[:i]
+			}
+		}
+	}
+
+	return ""
+}
+
+// acceptable reports whether the q parameter of an Accept-* element, if any, is
+// above zero.
+func acceptable(params map[string]string) bool {
+	q, ok := params["q"]
+	if !ok {
+		return true
+	}
+
+	weight, err := strconv.ParseFloat(q, 64)
+	return err == nil && weight > 0
+}
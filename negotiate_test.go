@@ -0,0 +1,120 @@
+package rfc7807
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestVary(t *testing.T) {
+	problems := New("http://example.com/errors", WithPrecompressedDocs(), WithDocVariantVary("Cookie"))
+	if _, err := problems.Doc("Plain", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.Doc("Translated", "", Localized("ja", []byte("<html></html>"))); err != nil {
+		t.Fatal(err)
+	}
+	problems.HtmlDoc("Compressed", []byte("<html></html>"))
+	problems.HtmlDocVariants("Audience", map[string][]byte{"public": []byte("public"), "internal": []byte("internal")})
+
+	problem := func(title string, r *http.Request) func(http.ResponseWriter) {
+		return func(w http.ResponseWriter) { problems.ErrorRequest(w, r, title, 400, "") }
+	}
+	page := func(target string) func(http.ResponseWriter) {
+		return func(w http.ResponseWriter) { problems.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil)) }
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	tests := []struct {
+		name  string
+		write func(http.ResponseWriter)
+		want  []string
+	}{
+		{"problem without request", problem("Plain", nil), nil},
+		{"problem", problem("Plain", r), []string{"Accept"}},
+		{"undocumented problem", problem("", r), []string{"Accept"}},
+		{"localized problem", problem("Translated", r), []string{"Accept", "Accept-Language"}},
+		{"doc page", page("/Plain.html"), []string{"Accept"}},
+		{"compressed doc page", page("/Compressed.html"), []string{"Accept", "Accept-Encoding"}},
+		{"doc page variants", page("/Audience.html"), []string{"Accept", "Cookie"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			test.write(w)
+			if got := w.Header()["Vary"]; !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Vary = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestVaryListed(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Vary", "Origin, accept")
+	vary(w, "Accept")
+	vary(w, "Accept-Language")
+
+	if got, want := w.Header()["Vary"], []string{"Origin, accept", "Accept-Language"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Vary = %q, want %q", got, want)
+	}
+}
+
+func TestBestOffer(t *testing.T) {
+	offers := []string{"application/problem+json", "application/json", "text/html", "application/xml"}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"*/*", "application/problem+json"},
+		{"application/xml;q=0.1, application/json;q=0.9", "application/json"},
+		{"application/json;q=0.1, application/xml;q=0.9", "application/xml"},
+		{"text/html, application/xhtml+xml, application/xml;q=0.9, */*;q=0.8", "text/html"},
+		{"application/json, text/html", "application/json"},
+		{"text/html;q=0.5, application/json;q=0.5", "text/html"},
+		{"application/*;q=0.5, application/xml", "application/xml"},
+		{"*/*, application/problem+json;q=0", "application/json"},
+		{"*/*;q=0.1, application/*;q=0", "text/html"},
+		{"application/json;q=0, application/problem+json;q=0", ""},
+		{"image/png", ""},
+		{"application/json;q=oops, text/html", "text/html"},
+		{"text/html;q=0.001, invalid/;q=1", "text/html"},
+	}
+
+	for _, test := range tests {
+		if got := bestOffer(test.accept, offers); got != test.want {
+			t.Errorf("bestOffer(%q) = %q, want %q", test.accept, got, test.want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	problems := New("http://example.com/errors", WithMarshaler("application/cbor", cborMarshaler{}))
+
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "application/problem+json; charset=utf-8"},
+		{"text/html;q=0.1, application/json;q=0.9", "application/problem+json; charset=utf-8"},
+		{"text/html;q=0.9, application/json;q=0.1", "text/html; charset=utf-8"},
+		{"application/cbor;q=0.5, application/problem+json;q=0.4", "application/problem+cbor"},
+		{"application/cbor;q=0, */*", "application/problem+json; charset=utf-8"},
+		{"text/html;q=0, application/cbor;q=0", "application/problem+json; charset=utf-8"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		problems.ErrorRequest(w, r, "", 404, "")
+
+		if w.Code != 404 || w.Header().Get("Content-Type") != test.contentType {
+			t.Errorf("Accept %q: %d %q, want 404 %q", test.accept, w.Code, w.Header().Get("Content-Type"), test.contentType)
+		}
+	}
+}
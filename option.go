@@ -0,0 +1,376 @@
+package rfc7807
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"reflect"
+)
+
+type Option func(*RFC7807)
+
+// WithNestedExtensions nests all extension members under a single member named key
+// (e.g. "properties", as Spring's ProblemDetail does) instead of inlining them.
+func WithNestedExtensions(key string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.nestedExtensions = key
+	}
+}
+
+// WithExtensionNamespace nests call-site extension members under a single
+// org-specific member (e.g. "acme"), keeping them clear of future standard
+// members. Members the library adds itself stay top-level.
+func WithExtensionNamespace(key string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.namespace = key
+	}
+}
+
+// WithPrettyQueryParam makes problems written by ErrorRequest compact unless the
+// request carries the named query parameter set to a true value (e.g. ?pretty=true).
+func WithPrettyQueryParam(name string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.prettyQueryParam = name
+	}
+}
+
+// WithFallbackDoc serves html as a generic doc page at /_generic.html and uses
+// it as the type of problems whose title has no registered doc.
+func WithFallbackDoc(html []byte) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.fallbackDoc = &doc{path: "/_generic.html", html: html}
+		rfc7807.fallbackDoc.url = rfc7807.serveDoc(rfc7807.fallbackDoc)
+	}
+}
+
+// WithLazyDocs defers baking of template and markdown docs until their page is
+// first requested. Template parse errors are still reported at registration.
+func WithLazyDocs() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.lazyDocs = true
+	}
+}
+
+// WithContentLocation sets the Content-Location header of documented problems
+// to the URL of their doc page.
+func WithContentLocation() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.contentLocation = true
+	}
+}
+
+// WithIRITypes emits type members as IRIs, with non-ASCII characters unescaped.
+// Doc routes and the Content-Location header keep the percent-encoded form.
+func WithIRITypes() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.iriTypes = true
+	}
+}
+
+// WithDebug enables debug-only output such as panic stack traces. Do not use it
+// in production: it exposes internals to clients.
+func WithDebug() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.debug = true
+	}
+}
+
+// WithGRPCCode makes GRPCProblem map code to status and to the problem registered
+// under title. An empty title uses the status text.
+func WithGRPCCode(code uint32, status int, title string) Option {
+	return func(rfc7807 *RFC7807) {
+		if rfc7807.grpcCodes == nil {
+			rfc7807.grpcCodes = map[uint32]grpcMapping{}
+		}
+		rfc7807.grpcCodes[code] = grpcMapping{status: status, title: title}
+	}
+}
+
+// WithAboutBlankType emits "type": "about:blank" for problems without a doc URL,
+// which RFC 9457 defines as equivalent to omitting type.
+func WithAboutBlankType() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.defaultType = "about:blank"
+	}
+}
+
+// WithAlwaysType gives every problem a type member, using uri (e.g.
+// "about:blank") for those without a doc URL, for clients whose schema requires
+// it. It undoes WithoutTypeMember.
+func WithAlwaysType(uri string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.defaultType = uri
+		rfc7807.withoutType = false
+	}
+}
+
+// WithLinkHeader mirrors describedby links of the "links" extension into the
+// HTTP Link header.
+func WithLinkHeader() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.linkHeader = true
+	}
+}
+
+// WithInstanceBase prefixes relative instance members with baseURL, so handlers
+// can pass paths like "/orders/5". Absolute instances are left unchanged.
+func WithInstanceBase(baseURL string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.instanceBase = baseURL
+	}
+}
+
+// WithDocVariantSelector picks, per request, which variant registered with
+// HtmlDocVariants a doc route serves (e.g. "public" or "internal").
+func WithDocVariantSelector(selector func(*http.Request) string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.docVariant = selector
+	}
+}
+
+// WithDocVariantVary lists the request headers the WithDocVariantSelector
+// function looks at, so variant doc pages carry a matching Vary header.
+func WithDocVariantVary(fields ...string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.docVariantVary = append(rfc7807.docVariantVary, fields...)
+	}
+}
+
+// WithUnprocessableTitle sets the title used by Unprocessable.
+func WithUnprocessableTitle(title string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.unprocessableTitle = title
+	}
+}
+
+// WithRequestIDHeader makes ErrorRequest echo the request ID found in the header
+// named header as the extension member named member. Requests without one get a
+// generated UUID, which is also set as the response header.
+func WithRequestIDHeader(header string, member string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.requestIDHeader = header
+		rfc7807.requestIDMember = member
+	}
+}
+
+// WithLogger sets the logger for warnings. The standard logger is used by default.
+func WithLogger(logger *log.Logger) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.logger = logger
+	}
+}
+
+// WithIncrementalFlush flushes problem and doc responses after the header and
+// again after the body, when the ResponseWriter is an http.Flusher, so each
+// part goes out under its own write deadline. Flushing the header early means
+// net/http cannot set Content-Length and responds with chunked encoding, and
+// any buffering middleware in front of the writer defeats it.
+func WithIncrementalFlush() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.incrementalFlush = true
+	}
+}
+
+// WithDocStylesheet adds css as a <style> element to the head of generated doc
+// pages: those of Doc and MarkdownDoc, and default HTML error pages.
+func WithDocStylesheet(css string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.docHead += "  <style>\n" + css + "\n  </style>\n"
+	}
+}
+
+// WithDocStylesheetURL is like WithDocStylesheet, but links the stylesheet at href.
+func WithDocStylesheetURL(href string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.docHead += `  <link rel="stylesheet" href="` + template.HTMLEscapeString(href) + "\">\n"
+	}
+}
+
+// WithExtensionEncoder transforms extension values of type t with encode before
+// they are encoded, e.g. to shape domain types that have no MarshalJSON.
+func WithExtensionEncoder(t reflect.Type, encode func(interface{}) interface{}) Option {
+	return func(rfc7807 *RFC7807) {
+		if rfc7807.extensionEncoders == nil {
+			rfc7807.extensionEncoders = map[reflect.Type]func(interface{}) interface{}{}
+		}
+		rfc7807.extensionEncoders[t] = encode
+	}
+}
+
+// WithoutTypeMember omits the type member from every problem. Doc pages are
+// still served for anyone who navigates to them.
+func WithoutTypeMember() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.withoutType = true
+	}
+}
+
+// WithSlugger derives the doc page name of a problem from its title with slugger
+// instead of using the title as it is. See Slugify.
+func WithSlugger(slugger func(title string) string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.slugger = slugger
+	}
+}
+
+// WithSlugSuffixes resolves doc page collisions between titles by appending
+// "-2", "-3", ... to the slug, instead of reporting them as errors.
+func WithSlugSuffixes() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.slugSuffixes = true
+	}
+}
+
+// WithInstanceClasses keeps the instance member only for the listed status
+// classes, e.g. WithInstanceClasses(4) for 4xx only, so 5xx problems do not
+// leak internal resource identifiers.
+func WithInstanceClasses(classes ...int) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.instanceClasses = map[int]bool{}
+		for _, class := range classes {
+			rfc7807.instanceClasses[class] = true
+		}
+	}
+}
+
+// WithDynamicBaseURL resolves type URLs in ErrorRequest against the base URL
+// returned for each request, e.g. built from r.Host and r.TLS, instead of the
+// base URL given to New. Doc routes are unchanged. An empty result falls back
+// to the static base URL.
+func WithDynamicBaseURL(baseURL func(*http.Request) string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.dynamicBaseURL = baseURL
+	}
+}
+
+// WithStrictTitles catches unregistered titles: Error logs them, ErrorE returns
+// an error and MustError panics.
+func WithStrictTitles() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.strictTitles = true
+	}
+}
+
+// WithPrecompressedDocs gzips HtmlDoc pages once at registration and serves the
+// compressed bytes to clients accepting gzip. It must precede the HtmlDoc calls.
+func WithPrecompressedDocs() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.precompressedDocs = true
+	}
+}
+
+// WithDerivedDetail fills in an empty detail from the number of items in the
+// extension named key, formatted with format (e.g. "errors" and "%d validation
+// errors occurred"). An explicit detail always wins.
+func WithDerivedDetail(key string, format string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.derivedDetailKey = key
+		rfc7807.derivedDetail = format
+	}
+}
+
+// WithSortedKeys writes extension members in alphabetical order, nested ones
+// included, after the standard members in their fixed order. The output is then
+// byte-stable whatever order extensions are passed in, e.g. for golden files.
+func WithSortedKeys() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.sortedKeys = true
+	}
+}
+
+// WithMaxExtensions keeps only the first n extensions passed to a problem and
+// drops the rest with a logged warning. Zero, the default, means no limit.
+func WithMaxExtensions(n int) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.maxExtensions = n
+	}
+}
+
+// WithDocLayout wraps the content of Doc, TemplateDoc and MarkdownDoc pages in
+// layout, for site-wide chrome. The layout gets the body of the page as
+// .Content, the problem title as .Title and the WithDocStylesheet elements as
+// .Head. It must precede the doc registrations.
+func WithDocLayout(layout *template.Template) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.docLayout = layout
+	}
+}
+
+// WithPathEscaper escapes doc page names with escaper instead of url.PathEscape,
+// e.g. to turn spaces into dashes. Doc routes and type URLs both use it, so they
+// stay in sync. Its output must be a valid escaped path segment.
+func WithPathEscaper(escaper func(string) string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.pathEscaper = escaper
+	}
+}
+
+// WithFinalizer calls finalize on every problem right before it is encoded, as
+// an escape hatch for interop quirks, e.g. renaming an extension for a legacy
+// client. r is nil for problems written without a request. Finalizers work on a
+// copy, which is also what the reporter gets, so a problem passed to
+// WriteProblem or ErrorMany is never modified.
+func WithFinalizer(finalize func(problem *Problem, r *http.Request)) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.finalizers = append(rfc7807.finalizers, finalize)
+	}
+}
+
+// WithGeneratedInstance gives problems without an instance a fresh UUID one,
+// prefixed with prefix (e.g. "urn:uuid:"), so every occurrence can be told
+// apart. An explicit instance wins.
+func WithGeneratedInstance(prefix string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.generateInstance = true
+		rfc7807.instancePrefix = prefix
+	}
+}
+
+// WithUUIDGenerator replaces the random UUIDs of WithGeneratedInstance and
+// WithRequestIDHeader with those of generate, e.g. for deterministic tests.
+func WithUUIDGenerator(generate func() string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.uuidGenerator = generate
+	}
+}
+
+// WithAdaptiveIndent indents problems only when their compact JSON is smaller
+// than threshold bytes, keeping small problems readable and large ones, such as
+// long "errors" lists, lean. WithPrettyQueryParam still applies below it.
+func WithAdaptiveIndent(threshold int) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.adaptiveIndent = threshold
+	}
+}
+
+// WithStatusTitles sets the titles of undocumented problems by status, e.g.
+// 404 to "Resource Not Found". Other statuses keep the status text.
+func WithStatusTitles(titles map[int]string) Option {
+	return func(rfc7807 *RFC7807) {
+		if rfc7807.statusTitles == nil {
+			rfc7807.statusTitles = map[int]string{}
+		}
+		for status, title := range titles {
+			rfc7807.statusTitles[status] = title
+		}
+	}
+}
+
+// WithEnvelope wraps the JSON problem body in the value envelope returns, e.g.
+// map[string]interface{}{"data": nil, "error": problem} for a legacy client.
+// Status and Content-Type are unchanged.
+func WithEnvelope(envelope func(problem json.RawMessage) interface{}) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.envelope = envelope
+	}
+}
+
+// WithValidateDocs checks that doc pages are well-formed HTML when registered,
+// at some startup cost. A broken page is reported as a registration error and
+// not served. Lazy docs are checked when first baked.
+func WithValidateDocs() Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.validateDocs = true
+	}
+}
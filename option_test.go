@@ -0,0 +1,737 @@
+package rfc7807
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWithNestedExtensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		want    map[string]interface{}
+	}{
+		{
+			name: "flat",
+			want: map[string]interface{}{
+				"title": "Out of Credit", "status": 403.0, "detail": "",
+				"balance": 30.0, "accounts": []interface{}{"/account/1"},
+			},
+		},
+		{
+			name:    "nested",
+			options: []Option{WithNestedExtensions("properties")},
+			want: map[string]interface{}{
+				"title": "Out of Credit", "status": 403.0, "detail": "",
+				"properties": map[string]interface{}{"balance": 30.0, "accounts": []interface{}{"/account/1"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", test.options...)
+			w := httptest.NewRecorder()
+			problems.Error(w, "Out of Credit", 403, "", Ext("balance", 30), Ext("accounts", []string{"/account/1"}))
+
+			if got := decode(t, w); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("problem = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithNestedExtensionsWithoutExtensions(t *testing.T) {
+	problems := New("http://example.com/errors", WithNestedExtensions("properties"))
+	w := httptest.NewRecorder()
+	problems.Error(w, "Out of Credit", 403, "")
+
+	if _, ok := decode(t, w)["properties"]; ok {
+		t.Error("empty properties member written")
+	}
+}
+
+func TestWithContentLocation(t *testing.T) {
+	problems := New("http://example.com/errors", WithContentLocation())
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	problems.Error(w, "NotFound", 404, "")
+	if got, want := w.Header().Get("Content-Location"), decode(t, w)["type"]; got != want {
+		t.Errorf("Content-Location = %q, want the type %q", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	problems.Error(w, "Gone", 410, "")
+	if got, ok := w.Header()["Content-Location"]; ok {
+		t.Errorf("Content-Location = %q for an undocumented problem", got)
+	}
+}
+
+func TestWithAboutBlankType(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		title   string
+		want    interface{}
+	}{
+		{"default titled", nil, "Gone", nil},
+		{"default untitled", nil, "", nil},
+		{"titled", []Option{WithAboutBlankType()}, "Gone", "about:blank"},
+		{"untitled", []Option{WithAboutBlankType()}, "", "about:blank"},
+		{"documented", []Option{WithAboutBlankType()}, "NotFound", "http://example.com/errors/NotFound.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", test.options...)
+			if _, err := problems.Doc("NotFound", "missing"); err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			problems.Error(w, test.title, 410, "")
+			if got := decode(t, w)["type"]; got != test.want {
+				t.Errorf("type = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithInstanceBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		instance *Extension
+		want     interface{}
+	}{
+		{"relative", Instance("/orders/5"), "https://api.example.com/orders/5"},
+		{"relative without slash", Instance("orders/5"), "https://api.example.com/orders/5"},
+		{"absolute", Instance("https://other.example.com/orders/5"), "https://other.example.com/orders/5"},
+		{"empty", Instance(""), nil},
+		{"none", nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", WithInstanceBase("https://api.example.com/"))
+			var extensions []*Extension
+			if test.instance != nil {
+				extensions = append(extensions, test.instance)
+			}
+
+			w := httptest.NewRecorder()
+			problems.Error(w, "Gone", 410, "", extensions...)
+			if got := decode(t, w)["instance"]; got != test.want {
+				t.Errorf("instance = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithRequestIDHeader(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	problems := New("http://example.com/errors", WithRequestIDHeader("X-Request-Id", "request_id"))
+
+	t.Run("present", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Request-Id", "abc-123")
+		w := httptest.NewRecorder()
+		problems.ErrorRequest(w, r, "Gone", 410, "")
+
+		if got := decode(t, w)["request_id"]; got != "abc-123" {
+			t.Errorf("request_id = %v, want the request header", got)
+		}
+		if got := w.Header().Get("X-Request-Id"); got != "abc-123" {
+			t.Errorf("X-Request-Id = %q, want the request header", got)
+		}
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		problems.ErrorRequest(w, httptest.NewRequest(http.MethodGet, "/", nil), "Gone", 410, "")
+
+		id, _ := decode(t, w)["request_id"].(string)
+		if !uuid.MatchString(id) {
+			t.Errorf("request_id = %q, want a generated UUID", id)
+		}
+		if got := w.Header().Get("X-Request-Id"); got != id {
+			t.Errorf("X-Request-Id = %q, want %q", got, id)
+		}
+	})
+}
+
+type amount struct {
+	units    int64
+	currency string
+}
+
+func TestWithExtensionEncoder(t *testing.T) {
+	problems := New("http://example.com/errors", WithExtensionEncoder(reflect.TypeOf(amount{}), func(v interface{}) interface{} {
+		a := v.(amount)
+		return fmt.Sprintf("%d.%02d %s", a.units/100, a.units%100, a.currency)
+	}))
+
+	w := httptest.NewRecorder()
+	problems.Error(w, "Out of Credit", 403, "", Ext("balance", amount{units: 3050, currency: "EUR"}), Ext("limit", 50))
+
+	problem := decode(t, w)
+	if problem["balance"] != "30.50 EUR" {
+		t.Errorf("balance = %v, want the encoded amount", problem["balance"])
+	}
+	if problem["limit"] != 50.0 {
+		t.Errorf("limit = %v, want it passed through", problem["limit"])
+	}
+}
+
+func TestWithoutTypeMember(t *testing.T) {
+	problems := New("http://example.com/errors", WithoutTypeMember(), WithFallbackDoc([]byte("<html></html>")))
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, title := range []string{"NotFound", "Gone", ""} {
+		w := httptest.NewRecorder()
+		problems.Error(w, title, 404, "", Ext("key", "value"))
+		if got, ok := decode(t, w)["type"]; ok {
+			t.Errorf("type = %v for %q, want none", got, title)
+		}
+	}
+
+	if w := serve(problems, http.MethodGet, "/NotFound.html", nil); w.Code != http.StatusOK {
+		t.Errorf("GET doc page = %d, want 200", w.Code)
+	}
+}
+
+func TestWithInstanceClasses(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		status  int
+		want    interface{}
+	}{
+		{"default 4xx", nil, 404, "/orders/5"},
+		{"default 5xx", nil, 500, "/orders/5"},
+		{"4xx only, 404", []Option{WithInstanceClasses(4)}, 404, "/orders/5"},
+		{"4xx only, 500", []Option{WithInstanceClasses(4)}, 500, nil},
+		{"none", []Option{WithInstanceClasses()}, 404, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", test.options...)
+			w := httptest.NewRecorder()
+			problems.Error(w, "", test.status, "", Instance("/orders/5"))
+
+			if got := decode(t, w)["instance"]; got != test.want {
+				t.Errorf("instance = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithExtensionNamespace(t *testing.T) {
+	problems := New("http://example.com/errors", WithExtensionNamespace("acme"), WithRequestIDHeader("X-Request-Id", "request_id"))
+	if _, err := problems.Doc("OutOfCredit", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	w := httptest.NewRecorder()
+	problems.ErrorRequest(w, r, "OutOfCredit", 403, "low", Ext("balance", 30), Instance("/account/1"), Ext("accounts", []string{"/account/1"}))
+
+	want := map[string]interface{}{
+		"type": "http://example.com/errors/OutOfCredit.html", "title": "OutOfCredit", "status": 403.0, "detail": "low",
+		"instance": "/account/1", "request_id": "abc",
+		"acme": map[string]interface{}{"balance": 30.0, "accounts": []interface{}{"/account/1"}},
+	}
+	if got := decode(t, w); !reflect.DeepEqual(got, want) {
+		t.Errorf("problem = %v, want %v", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	problems.Error(w, "OutOfCredit", 403, "")
+	if _, ok := decode(t, w)["acme"]; ok {
+		t.Error("empty namespace member written")
+	}
+}
+
+func TestWithDerivedDetail(t *testing.T) {
+	violations := []Violation{{Field: "name", Message: "is required"}, {Field: "age", Message: "must be positive"}, {Field: "email", Message: "is invalid"}}
+
+	tests := []struct {
+		name       string
+		detail     string
+		extensions []*Extension
+		want       string
+	}{
+		{"derived", "", []*Extension{Ext("errors", violations)}, "3 validation errors occurred"},
+		{"explicit", "Check your input.", []*Extension{Ext("errors", violations)}, "Check your input."},
+		{"empty list", "", []*Extension{Ext("errors", []Violation{})}, ""},
+		{"no list", "", []*Extension{Ext("other", violations)}, ""},
+		{"not a list", "", []*Extension{Ext("errors", "three")}, ""},
+	}
+
+	problems := New("http://example.com/errors", WithDerivedDetail("errors", "%d validation errors occurred"))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			problems.Error(w, "", 422, test.detail, test.extensions...)
+			if got := decode(t, w)["detail"]; got != test.want {
+				t.Errorf("detail = %v, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithSortedKeys(t *testing.T) {
+	problems := New("http://example.com/errors", WithSortedKeys(), WithPrettyQueryParam("pretty"))
+	want := `{"title":"Out of Credit","status":403,"detail":"","accounts":["/account/1"],"balance":30,"limits":{"daily":10,"monthly":300},"zone":"eu"}` + "\n"
+
+	for i := 0; i < 20; i++ {
+		limits := map[string]interface{}{"monthly": 300, "daily": 10}
+		extensions := []*Extension{Ext("zone", "eu"), Ext("balance", 30), Ext("limits", limits), Ext("accounts", []string{"/account/1"})}
+		// Vary the order extensions are passed in.
+		rand.Shuffle(len(extensions), func(i, j int) { extensions[i], extensions[j] = extensions[j], extensions[i] })
+
+		w := httptest.NewRecorder()
+		problems.Error(w, "Out of Credit", 403, "", extensions...)
+		if w.Body.String() != want {
+			t.Fatalf("run %d:\ngot  %s\nwant %s", i, w.Body.String(), want)
+		}
+	}
+}
+
+func TestWithSortedKeysNested(t *testing.T) {
+	problems := New("http://example.com/errors", WithSortedKeys(), WithNestedExtensions("properties"), WithPrettyQueryParam("pretty"))
+	w := httptest.NewRecorder()
+	problems.Error(w, "Out of Credit", 403, "", Ext("zone", "eu"), Ext("balance", 30))
+
+	if want := `{"title":"Out of Credit","status":403,"detail":"","properties":{"balance":30,"zone":"eu"}}` + "\n"; w.Body.String() != want {
+		t.Errorf("got  %s\nwant %s", w.Body.String(), want)
+	}
+}
+
+func TestWithMaxExtensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		want    []string
+		warning string
+	}{
+		{"unlimited", nil, []string{"a", "b", "c", "d"}, ""},
+		{"under the limit", []Option{WithMaxExtensions(5)}, []string{"a", "b", "c", "d"}, ""},
+		{"at the limit", []Option{WithMaxExtensions(4)}, []string{"a", "b", "c", "d"}, ""},
+		{"over the limit", []Option{WithMaxExtensions(2)}, []string{"a", "b"}, "dropped 2 extensions over the limit of 2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			problems := New("http://example.com/errors", append(test.options, WithLogger(log.New(&logs, "", 0)))...)
+
+			w := httptest.NewRecorder()
+			problems.Error(w, "", 400, "", Ext("a", 1), Ext("b", 2), Ext("c", 3), Ext("d", 4))
+			problem := decode(t, w)
+
+			var got []string
+			for _, key := range []string{"a", "b", "c", "d"} {
+				if _, ok := problem[key]; ok {
+					got = append(got, key)
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("extensions = %v, want %v", got, test.want)
+			}
+			if test.warning == "" && logs.Len() != 0 || !strings.Contains(logs.String(), test.warning) {
+				t.Errorf("logged %q, want %q", logs.String(), test.warning)
+			}
+		})
+	}
+}
+
+func TestWithDocLayout(t *testing.T) {
+	layout := template.Must(template.New("layout").Parse("<html><head><title>{{.Title}} | Docs</title>{{.Head}}</head><body><nav>site</nav>{{.Content}}<footer>end</footer></body></html>"))
+	problems := New("http://example.com/errors", WithDocLayout(layout), WithDocStylesheet("h1{}"))
+	if _, err := problems.Doc("Generated", "generated description"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.TemplateDoc("Templated", "", "<html><body><h1>{{.Title}} template</h1></body></html>"); err != nil {
+		t.Fatal(err)
+	}
+	problems.MarkdownDoc("Markdown", []byte("Plain markdown paragraph."))
+
+	tests := []struct {
+		title   string
+		content string
+	}{
+		{"Generated", "generated description"},
+		{"Templated", "<h1>Templated template</h1>"},
+		{"Markdown", "Plain markdown paragraph."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			page := serve(problems, http.MethodGet, "/"+test.title+".html", nil).Body.String()
+
+			prefix := "<html><head><title>" + test.title + " | Docs</title>"
+			if !strings.HasPrefix(page, prefix) || !strings.HasSuffix(page, "<footer>end</footer></body></html>") {
+				t.Errorf("page not wrapped in the layout: %s", page)
+			}
+			if !strings.Contains(page, "<style>\nh1{}\n  </style>") {
+				t.Errorf("page lacks the stylesheet: %s", page)
+			}
+			nav, content := strings.Index(page, "<nav>site</nav>"), strings.Index(page, test.content)
+			if nav < 0 || content < nav {
+				t.Errorf("content %q not inside the layout body: %s", test.content, page)
+			}
+			if strings.Count(page, "<html") != 1 || strings.Count(page, "<body") != 1 {
+				t.Errorf("page nests documents: %s", page)
+			}
+		})
+	}
+}
+
+func TestWithAlwaysType(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		title   string
+		want    interface{}
+	}{
+		{"documented", []Option{WithAlwaysType("about:blank")}, "NotFound", "http://example.com/errors/NotFound.html"},
+		{"undocumented", []Option{WithAlwaysType("about:blank")}, "Gone Away", "about:blank"},
+		{"status title", []Option{WithAlwaysType("about:blank")}, "", "about:blank"},
+		{"custom URI", []Option{WithAlwaysType("https://example.com/probs/generic")}, "Gone Away", "https://example.com/probs/generic"},
+		{"after WithoutTypeMember", []Option{WithoutTypeMember(), WithAlwaysType("about:blank")}, "NotFound", "http://example.com/errors/NotFound.html"},
+		{"without the option", nil, "Gone Away", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", test.options...)
+			if _, err := problems.Doc("NotFound", "missing"); err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			problems.Error(w, test.title, 404, "")
+			if got := decode(t, w)["type"]; got != test.want {
+				t.Errorf("type = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithPathEscaper(t *testing.T) {
+	tests := []struct {
+		name    string
+		escaper func(string) string
+		path    string
+	}{
+		{"dashes", func(name string) string { return url.PathEscape(strings.ReplaceAll(name, " ", "-")) }, "/Out-of-Credit~1.html"},
+		{"plus", func(name string) string { return strings.ReplaceAll(url.PathEscape(name), "%20", "+") }, "/Out+of+Credit~1.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", WithPathEscaper(test.escaper))
+			if _, err := problems.Doc("Out of Credit~1", "no credit"); err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			problems.Error(w, "Out of Credit~1", 403, "")
+			if got := decode(t, w)["type"]; got != "http://example.com/errors"+test.path {
+				t.Errorf("type = %v, want %q", got, test.path)
+			}
+			if page := serve(problems, http.MethodGet, test.path, nil); page.Code != http.StatusOK || !strings.Contains(page.Body.String(), "no credit") {
+				t.Errorf("GET %s = %d, want the doc page", test.path, page.Code)
+			}
+			if errs := problems.Verify(); len(errs) != 0 {
+				t.Errorf("Verify() = %v", errs)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("Out of Credit~1", ""); err != nil {
+		t.Fatal(err)
+	}
+	problems.Error(w, "Out of Credit~1", 403, "")
+	if got := decode(t, w)["type"]; got != "http://example.com/errors/Out%20of%20Credit~1.html" {
+		t.Errorf("type with the default escaper = %v", got)
+	}
+}
+
+func TestWithFinalizer(t *testing.T) {
+	// A legacy client expects "code" where the problem carries "error_code", and
+	// the title in the detail of problems without one.
+	rename := func(problem *Problem, r *http.Request) {
+		for _, extension := range problem.Extensions {
+			if extension.Key == "error_code" {
+				extension.Key = "code"
+			}
+		}
+	}
+	fillDetail := func(problem *Problem, r *http.Request) {
+		if problem.Detail == "" {
+			problem.Detail = problem.Title
+		}
+		if r != nil {
+			problem.Extensions = append(problem.Extensions, Ext("path", r.URL.Path))
+		}
+	}
+	problems := New("http://example.com/errors", WithFinalizer(rename), WithFinalizer(fillDetail))
+
+	w := httptest.NewRecorder()
+	problems.ErrorRequest(w, httptest.NewRequest(http.MethodGet, "/orders/5", nil), "Out of Credit", 403, "", Ext("error_code", "E42"))
+	problem := decode(t, w)
+	if _, ok := problem["error_code"]; ok || problem["code"] != "E42" {
+		t.Errorf("problem = %v, want error_code renamed to code", problem)
+	}
+	if problem["detail"] != "Out of Credit" || problem["path"] != "/orders/5" {
+		t.Errorf("problem = %v, want the later finalizer applied too", problem)
+	}
+
+	w = httptest.NewRecorder()
+	problems.Error(w, "Out of Credit", 403, "low", Ext("error_code", "E42"))
+	if problem := decode(t, w); problem["code"] != "E42" || problem["detail"] != "low" || problem["path"] != nil {
+		t.Errorf("problem without a request = %v", problem)
+	}
+}
+
+func TestWithGeneratedInstance(t *testing.T) {
+	instance := func(problems *RFC7807, extensions ...*Extension) interface{} {
+		w := httptest.NewRecorder()
+		problems.Error(w, "Out of Credit", 403, "", extensions...)
+		return decode(t, w)["instance"]
+	}
+
+	t.Run("unique", func(t *testing.T) {
+		problems := New("http://example.com/errors", WithGeneratedInstance("urn:uuid:"))
+		uuid := regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+		first, second := instance(problems), instance(problems)
+		for _, got := range []interface{}{first, second} {
+			if s, _ := got.(string); !uuid.MatchString(s) {
+				t.Errorf("instance = %v, want a prefixed version 4 UUID", got)
+			}
+		}
+		if first == second {
+			t.Errorf("two problems share the instance %v", first)
+		}
+	})
+
+	t.Run("injected generator", func(t *testing.T) {
+		generator := func() func() string {
+			n := 0
+			return func() string {
+				n++
+				return fmt.Sprintf("id-%d", n)
+			}
+		}
+
+		for run := 0; run < 2; run++ {
+			problems := New("http://example.com/errors", WithGeneratedInstance("urn:test:"), WithUUIDGenerator(generator()))
+			if got := []interface{}{instance(problems), instance(problems)}; !reflect.DeepEqual(got, []interface{}{"urn:test:id-1", "urn:test:id-2"}) {
+				t.Errorf("run %d: instances = %v", run, got)
+			}
+		}
+	})
+
+	t.Run("explicit instance", func(t *testing.T) {
+		problems := New("http://example.com/errors", WithGeneratedInstance("urn:uuid:"))
+		if got := instance(problems, Instance("/orders/5")); got != "/orders/5" {
+			t.Errorf("instance = %v, want the explicit one", got)
+		}
+	})
+}
+
+// violations returns n violations, for problems of growing size.
+func violations(n int) []Violation {
+	list := make([]Violation, n)
+	for i := range list {
+		list[i] = Violation{Field: fmt.Sprintf("items.%d.name", i), Message: "is required"}
+	}
+	return list
+}
+
+func TestWithAdaptiveIndent(t *testing.T) {
+	compactSize := func(n int) int {
+		w := httptest.NewRecorder()
+		New("http://example.com/errors", WithPrettyQueryParam("pretty")).Unprocessable(w, violations(n)...)
+		return w.Body.Len() - len("\n")
+	}
+
+	tests := []struct {
+		name      string
+		n         int
+		threshold int
+		indented  bool
+	}{
+		{"empty", 0, 1024, true},
+		{"small", 1, 1024, true},
+		{"large", 100, 1024, false},
+		{"just below", 10, compactSize(10) + 1, true},
+		{"at threshold", 10, compactSize(10), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", WithAdaptiveIndent(test.threshold))
+			w := httptest.NewRecorder()
+			problems.Unprocessable(w, violations(test.n)...)
+
+			if len(decode(t, w)["errors"].([]interface{})) != test.n {
+				t.Fatalf("body %s lost violations", w.Body.String())
+			}
+			if indented := strings.Contains(w.Body.String(), "\n  \""); indented != test.indented {
+				t.Errorf("%d bytes compact, threshold %d: indented = %v, want %v", compactSize(test.n), test.threshold, indented, test.indented)
+			}
+			if !strings.HasSuffix(w.Body.String(), "}\n") {
+				t.Errorf("body %q does not end with a newline", w.Body.String())
+			}
+		})
+	}
+
+	problems := New("http://example.com/errors", WithAdaptiveIndent(1024), WithPrettyQueryParam("pretty"))
+	w := httptest.NewRecorder()
+	problems.ErrorRequest(w, httptest.NewRequest(http.MethodGet, "/", nil), "", 400, "")
+	if strings.Count(w.Body.String(), "\n") != 1 {
+		t.Errorf("small problem indented without the pretty query parameter: %s", w.Body.String())
+	}
+}
+
+func BenchmarkAdaptiveIndent(b *testing.B) {
+	for _, n := range []int{1, 10, 100, 1000} {
+		list := violations(n)
+		for _, mode := range []struct {
+			name    string
+			options []Option
+		}{
+			{"indented", nil},
+			{"adaptive", []Option{WithAdaptiveIndent(1024)}},
+		} {
+			problems := New("http://example.com/errors", mode.options...)
+			b.Run(fmt.Sprintf("%s/%d", mode.name, n), func(b *testing.B) {
+				w := discardWriter{header: http.Header{}}
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					problems.Unprocessable(w, list...)
+				}
+			})
+		}
+	}
+}
+
+func TestWithStatusTitles(t *testing.T) {
+	problems := New("http://example.com/errors", WithStatusTitles(map[int]string{404: "Resource Not Found", 503: "Try Again Later"}))
+	if _, err := problems.Doc("Gone Missing", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		title  string
+		status int
+		want   string
+	}{
+		{"", 404, "Resource Not Found"},
+		{"", 503, "Try Again Later"},
+		{"", 500, "Internal Server Error"},
+		{"Gone Missing", 404, "Gone Missing"},
+	}
+
+	for _, test := range tests {
+		write := map[string]func(w *httptest.ResponseRecorder){
+			"Error": func(w *httptest.ResponseRecorder) { problems.Error(w, test.title, test.status, "") },
+			"ErrorRequest": func(w *httptest.ResponseRecorder) {
+				problems.ErrorRequest(w, httptest.NewRequest(http.MethodGet, "/", nil), test.title, test.status, "")
+			},
+		}
+		for name, write := range write {
+			w := httptest.NewRecorder()
+			write(w)
+			if got := decode(t, w)["title"]; got != test.want {
+				t.Errorf("%s(%q, %d): title = %v, want %q", name, test.title, test.status, got, test.want)
+			}
+		}
+	}
+}
+
+func TestWithEnvelope(t *testing.T) {
+	envelope := func(problem json.RawMessage) interface{} {
+		return map[string]interface{}{"data": nil, "error": problem}
+	}
+	problems := New("http://example.com/errors", WithEnvelope(envelope), WithPrettyQueryParam("pretty"))
+
+	write := map[string]func(w *httptest.ResponseRecorder){
+		"Error": func(w *httptest.ResponseRecorder) { problems.Error(w, "", 404, "no user", Ext("id", 7)) },
+		"ErrorRequest": func(w *httptest.ResponseRecorder) {
+			problems.ErrorRequest(w, httptest.NewRequest(http.MethodGet, "/", nil), "", 404, "no user", Ext("id", 7))
+		},
+	}
+	for name, write := range write {
+		w := httptest.NewRecorder()
+		write(w)
+
+		if want := `{"data":null,"error":{"title":"Not Found","status":404,"detail":"no user","id":7}}` + "\n"; w.Body.String() != want {
+			t.Errorf("%s:\ngot  %s\nwant %s", name, w.Body.String(), want)
+		}
+		if w.Code != 404 || w.Header().Get("Content-Type") != "application/problem+json; charset=utf-8" {
+			t.Errorf("%s: %d %q, want the problem status and Content-Type", name, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	New("http://example.com/errors", WithPrettyQueryParam("pretty")).Error(w, "", 404, "no user")
+	if want := `{"title":"Not Found","status":404,"detail":"no user"}` + "\n"; w.Body.String() != want {
+		t.Errorf("without an envelope:\ngot  %s\nwant %s", w.Body.String(), want)
+	}
+}
+
+func TestWithDocVariantSelector(t *testing.T) {
+	audience := func(r *http.Request) string { return r.Header.Get("X-Audience") }
+	problems := New("http://example.com/errors", WithDocVariantSelector(audience))
+	problems.HtmlDocVariants("OutOfCredit", map[string][]byte{
+		"public":   []byte("<p>Top up your account.</p>"),
+		"internal": []byte("<p>See the billing runbook.</p>"),
+	})
+
+	tests := []struct {
+		audience string
+		want     string
+	}{
+		{"", "<p>Top up your account.</p>"},
+		{"internal", "<p>See the billing runbook.</p>"},
+		{"partner", "<p>Top up your account.</p>"},
+	}
+
+	for _, test := range tests {
+		w := serve(problems, http.MethodGet, "/OutOfCredit.html", http.Header{"X-Audience": {test.audience}})
+		if w.Code != http.StatusOK || w.Body.String() != test.want {
+			t.Errorf("audience %q: page = %d %q, want %q", test.audience, w.Code, w.Body.String(), test.want)
+		}
+	}
+
+	// Without a selector, the public variant is served.
+	problems = New("http://example.com/errors")
+	problems.HtmlDocVariants("OutOfCredit", map[string][]byte{"public": []byte("public"), "internal": []byte("internal")})
+	if w := serve(problems, http.MethodGet, "/OutOfCredit.html", http.Header{"X-Audience": {"internal"}}); w.Body.String() != "public" {
+		t.Errorf("page without a selector = %q, want the public variant", w.Body.String())
+	}
+}
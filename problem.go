@@ -0,0 +1,392 @@
+package rfc7807
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions []*Extension
+}
+
+var reservedMembers = map[string]bool{
+	"type":     true,
+	"title":    true,
+	"status":   true,
+	"detail":   true,
+	"instance": true,
+}
+
+// MarshalJSON writes the standard members followed by the extensions in order.
+// Every key and value goes through json.Marshal, so nothing is concatenated
+// unescaped. Extensions named like a standard member are dropped and, for
+// repeated keys, the last extension wins.
+func (problem *Problem) MarshalJSON() ([]byte, error) {
+	object := newJSONObject()
+
+	if problem.Type != "" {
+		if err := object.member("type", problem.Type); err != nil {
+			return nil, err
+		}
+	}
+	if err := object.member("title", problem.Title); err != nil {
+		return nil, err
+	}
+	if err := object.member("status", problem.Status); err != nil {
+		return nil, err
+	}
+	if err := object.member("detail", problem.Detail); err != nil {
+		return nil, err
+	}
+	if problem.Instance != "" {
+		if err := object.member("instance", problem.Instance); err != nil {
+			return nil, err
+		}
+	}
+
+	extensions := make([]*Extension, 0, len(problem.Extensions))
+	for _, extension := range problem.Extensions {
+		if !reservedMembers[extension.Key] {
+			extensions = append(extensions, extension)
+		}
+	}
+	if err := object.extensions(extensions); err != nil {
+		return nil, err
+	}
+
+	return object.bytes(), nil
+}
+
+// Validate checks problem against RFC 7807: status is an HTTP status code, type
+// and instance are URI references, and no extension uses a standard member
+// name. The error lists every violation found.
+func (problem *Problem) Validate() error {
+	var violations []string
+
+	if problem.Status < 100 || problem.Status > 599 {
+		violations = append(violations, fmt.Sprintf("status %d is not an HTTP status code", problem.Status))
+	}
+	if _, err := url.Parse(problem.Type); err != nil {
+		violations = append(violations, fmt.Sprintf("type %q is not a URI reference", problem.Type))
+	}
+	if _, err := url.Parse(problem.Instance); err != nil {
+		violations = append(violations, fmt.Sprintf("instance %q is not a URI reference", problem.Instance))
+	}
+	for _, extension := range problem.Extensions {
+		if reservedMembers[extension.Key] {
+			violations = append(violations, fmt.Sprintf("extension %q collides with a standard member", extension.Key))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("rfc7807: invalid problem: %s", strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+// Map returns the members of problem as MarshalJSON would write them.
+func (problem *Problem) Map() map[string]interface{} {
+	m := extensionObject(problem.Extensions).Map()
+	for key := range reservedMembers {
+		delete(m, key)
+	}
+
+	if problem.Type != "" {
+		m["type"] = problem.Type
+	}
+	m["title"] = problem.Title
+	m["status"] = problem.Status
+	m["detail"] = problem.Detail
+	if problem.Instance != "" {
+		m["instance"] = problem.Instance
+	}
+
+	return m
+}
+
+// UnmarshalJSON reads a problem object, keeping the order of its extensions.
+// Standard members of the wrong JSON type are ignored, as RFC 9457 requires.
+func (problem *Problem) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if token, err := decoder.Token(); err != nil {
+		return err
+	} else if token != json.Delim('{') {
+		return fmt.Errorf("rfc7807: problem is not a JSON object")
+	}
+
+	p := Problem{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+
+		switch key {
+		case "type":
+			json.Unmarshal(value, &p.Type)
+		case "title":
+			json.Unmarshal(value, &p.Title)
+		case "status":
+			json.Unmarshal(value, &p.Status)
+		case "detail":
+			json.Unmarshal(value, &p.Detail)
+		case "instance":
+			json.Unmarshal(value, &p.Instance)
+		default:
+			var v interface{}
+			valueDecoder := json.NewDecoder(bytes.NewReader(value))
+			valueDecoder.UseNumber()
+			if err := valueDecoder.Decode(&v); err != nil {
+				return err
+			}
+			p.Extensions = append(p.Extensions, Ext(key, v))
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+
+	*problem = p
+	return nil
+}
+
+// ParseProblem parses a problem+json document, e.g. one received from an upstream service.
+func ParseProblem(data []byte) (*Problem, error) {
+	problem := &Problem{}
+	if err := json.Unmarshal(data, problem); err != nil {
+		return nil, err
+	}
+
+	return problem, nil
+}
+
+// Merge returns a copy of problem with overrides applied on top, leaving both
+// unchanged. Non-empty standard members of overrides replace those of problem.
+// Extensions are united; on a key collision the one of overrides wins and the
+// extensions of overrides come last.
+func (problem *Problem) Merge(overrides *Problem) *Problem {
+	merged := *problem
+	merged.Extensions = append([]*Extension(nil), problem.Extensions...)
+	if overrides == nil {
+		return &merged
+	}
+
+	if overrides.Type != "" {
+		merged.Type = overrides.Type
+	}
+	if overrides.Title != "" {
+		merged.Title = overrides.Title
+	}
+	if overrides.Status != 0 {
+		merged.Status = overrides.Status
+	}
+	if overrides.Detail != "" {
+		merged.Detail = overrides.Detail
+	}
+	if overrides.Instance != "" {
+		merged.Instance = overrides.Instance
+	}
+
+	overridden := make(map[string]bool, len(overrides.Extensions))
+	for _, extension := range overrides.Extensions {
+		overridden[extension.Key] = true
+	}
+
+	extensions := make([]*Extension, 0, len(merged.Extensions)+len(overrides.Extensions))
+	for _, extension := range merged.Extensions {
+		if !overridden[extension.Key] {
+			extensions = append(extensions, extension)
+		}
+	}
+	merged.Extensions = append(extensions, overrides.Extensions...)
+
+	return &merged
+}
+
+// extensionObject marshals extensions as a single JSON object, keeping their order.
+type extensionObject []*Extension
+
+func (extensions extensionObject) MarshalJSON() ([]byte, error) {
+	object := newJSONObject()
+	if err := object.extensions(extensions); err != nil {
+		return nil, err
+	}
+
+	return object.bytes(), nil
+}
+
+func (extensions extensionObject) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(extensions))
+	for _, extension := range extensions {
+		value, ok := encodedValue(extension.Value)
+		if !ok {
+			delete(m, extension.Key)
+			continue
+		}
+		m[extension.Key] = mapValue(value)
+	}
+
+	return m
+}
+
+// mapValue converts the problems and extension objects in value, at any depth
+// of slices, to maps, so marshalers see plain data only.
+func mapValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case extensionObject:
+		return value.Map()
+	case *Problem:
+		if value == nil {
+			return nil
+		}
+		return value.Map()
+	case []extensionObject:
+		values := make([]interface{}, len(value))
+		for i, nested := range value {
+			values[i] = nested.Map()
+		}
+		return values
+	case []*Problem:
+		values := make([]interface{}, len(value))
+		for i, nested := range value {
+			values[i] = mapValue(nested)
+		}
+		return values
+	case []interface{}:
+		values := make([]interface{}, len(value))
+		for i, nested := range value {
+			values[i] = mapValue(nested)
+		}
+		return values
+	}
+
+	return value
+}
+
+// encodedValue returns the value written for an extension, or false if it is
+// withheld. ExtFunc and ExtBytes values left at this point come from problems
+// not built by the instance, e.g. those given to WriteProblem: the former are
+// computed, and the latter, which never went through the WithDebug check, withheld.
+func encodedValue(value interface{}) (interface{}, bool) {
+	switch value := value.(type) {
+	case lazyValue:
+		return value(), true
+	case debugBytes:
+		return nil, false
+	}
+
+	return value, true
+}
+
+// sorted returns a copy of extensions ordered by key, nested objects included.
+// Repeated keys keep their relative order, so the last one still wins.
+func (extensions extensionObject) sorted() extensionObject {
+	sorted := make(extensionObject, len(extensions))
+	for i, extension := range extensions {
+		if nested, ok := extension.Value.(extensionObject); ok {
+			extension = Ext(extension.Key, nested.sorted())
+		}
+		sorted[i] = extension
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	return sorted
+}
+
+type jsonObject struct {
+	buf     *bytes.Buffer
+	members int
+}
+
+func newJSONObject() *jsonObject {
+	buf := bytes.NewBuffer(make([]byte, 0, 256))
+	buf.WriteByte('{')
+	return &jsonObject{buf: buf}
+}
+
+func (object *jsonObject) member(key string, value interface{}) error {
+	k, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if object.members > 0 {
+		object.buf.WriteByte(',')
+	}
+	object.buf.Write(k)
+	object.buf.WriteByte(':')
+	object.buf.Write(v)
+	object.members++
+	return nil
+}
+
+func (object *jsonObject) extensions(extensions []*Extension) error {
+	last := make(map[string]int, len(extensions))
+	for i, extension := range extensions {
+		last[extension.Key] = i
+	}
+
+	for i, extension := range extensions {
+		if last[extension.Key] != i {
+			continue
+		}
+		value, ok := encodedValue(extension.Value)
+		if !ok {
+			continue
+		}
+		if err := object.member(extension.Key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (object *jsonObject) bytes() []byte {
+	object.buf.WriteByte('}')
+	return object.buf.Bytes()
+}
+
+// EncodeNDJSON writes problems as newline-delimited JSON, one compact problem per line.
+func (rfc7807 *RFC7807) EncodeNDJSON(w io.Writer, problems ...*Problem) error {
+	for _, problem := range problems {
+		line, err := json.Marshal(problem)
+		if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package rfc7807
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+)
+
+func FuzzProblemMarshalJSON(f *testing.F) {
+	f.Add("Not Found", "no \"user\" with id \\1", "key", "value")
+	f.Add("\x00\x01\x1f\x7f", "line\nbreak\ttab\rreturn", "\b\f", "</script><!--&")
+	f.Add("日本語", "  ", "emoji 🙂", "�")
+	f.Add("\xff\xfe", "\xc3\x28", "\xed\xa0\x80", "")
+	f.Add("", "", "title", "shadowed")
+
+	f.Fuzz(func(t *testing.T, title string, detail string, key string, value string) {
+		problem := &Problem{Title: title, Status: 400, Detail: detail, Extensions: []*Extension{Ext(key, value)}}
+		b, err := json.Marshal(problem)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		if !json.Valid(b) {
+			t.Fatalf("invalid JSON %q", b)
+		}
+
+		members := map[string]interface{}{}
+		if err := json.Unmarshal(b, &members); err != nil {
+			t.Fatal(err)
+		}
+
+		// Invalid UTF-8 is replaced with U+FFFD, so only valid strings survive as is.
+		want := map[string]string{"title": title, "detail": detail}
+		if !reservedMembers[key] {
+			want[key] = value
+		}
+		for member, s := range want {
+			if !utf8.ValidString(member) || !utf8.ValidString(s) {
+				continue
+			}
+			if members[member] != s {
+				t.Errorf("%s = %q, want %q", member, members[member], s)
+			}
+		}
+	})
+}
+
+var problemSeeds = []string{
+	`{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","status":403,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc","balance":30,"accounts":["/account/12345","/account/67890"]}`,
+	`{"title":"Not Found","status":404}`,
+	`{"status":"404","title":42,"type":null,"nested":{"a":[1,2.5,true,null]}}`,
+	`{"a":1,"a":2,"b":"\u0000\u2028"}`,
+	`{}`,
+	`[]`,
+	`{"title":`,
+	`null`,
+	``,
+}
+
+func FuzzParseProblem(f *testing.F) {
+	for _, seed := range problemSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		problem, err := ParseProblem(data)
+		if err == nil && problem == nil {
+			t.Fatal("nil problem without error")
+		}
+	})
+}
+
+func FuzzProblemRoundTrip(f *testing.F) {
+	for _, seed := range problemSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		problem, err := ParseProblem(data)
+		if err != nil {
+			return
+		}
+
+		first, err := json.Marshal(problem)
+		if err != nil {
+			t.Fatalf("Marshal(%q): %v", data, err)
+		}
+		reparsed, err := ParseProblem(first)
+		if err != nil {
+			t.Fatalf("ParseProblem(%q): %v", first, err)
+		}
+		second, err := json.Marshal(reparsed)
+		if err != nil {
+			t.Fatalf("Marshal(%q): %v", first, err)
+		}
+
+		if !bytes.Equal(first, second) {
+			t.Errorf("unstable round trip of %q:\n%s\n%s", data, first, second)
+		}
+	})
+}
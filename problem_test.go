@@ -0,0 +1,136 @@
+package rfc7807
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProblemMerge(t *testing.T) {
+	upstream := &Problem{
+		Type:       "https://upstream.example.com/out-of-credit",
+		Title:      "Out of Credit",
+		Status:     403,
+		Detail:     "upstream detail",
+		Instance:   "/upstream/1",
+		Extensions: []*Extension{Ext("balance", 30), Ext("trace", "u")},
+	}
+	overrides := &Problem{
+		Instance:   "/orders/5",
+		Extensions: []*Extension{Ext("trace", "local"), Ext("order", 5)},
+	}
+
+	merged := upstream.Merge(overrides)
+
+	want := &Problem{
+		Type:       "https://upstream.example.com/out-of-credit",
+		Title:      "Out of Credit",
+		Status:     403,
+		Detail:     "upstream detail",
+		Instance:   "/orders/5",
+		Extensions: []*Extension{Ext("balance", 30), Ext("trace", "local"), Ext("order", 5)},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("Merge() = %+v, want %+v", merged, want)
+	}
+
+	if upstream.Instance != "/upstream/1" || len(upstream.Extensions) != 2 || upstream.Extensions[1].Value != "u" {
+		t.Errorf("Merge() changed the problem: %+v", upstream)
+	}
+	if len(overrides.Extensions) != 2 {
+		t.Errorf("Merge() changed the overrides: %+v", overrides)
+	}
+}
+
+func TestProblemMergeStandardMembers(t *testing.T) {
+	problem := &Problem{Type: "a", Title: "A", Status: 400, Detail: "a", Instance: "/a"}
+	overrides := &Problem{Type: "b", Title: "B", Status: 409, Detail: "b", Instance: "/b"}
+
+	if merged := problem.Merge(overrides); !reflect.DeepEqual(merged, &Problem{Type: "b", Title: "B", Status: 409, Detail: "b", Instance: "/b", Extensions: []*Extension{}}) {
+		t.Errorf("Merge() = %+v, want every member overridden", merged)
+	}
+	if merged := problem.Merge(&Problem{}); merged.Title != "A" || merged.Status != 400 || merged.Type != "a" {
+		t.Errorf("Merge() with empty overrides = %+v, want the problem", merged)
+	}
+	if merged := problem.Merge(nil); !reflect.DeepEqual(*merged, *problem) || merged == problem {
+		t.Errorf("Merge(nil) = %+v, want a copy of the problem", merged)
+	}
+}
+
+func TestProblemValidate(t *testing.T) {
+	valid := func() *Problem {
+		return &Problem{Type: "http://example.com/errors/NotFound.html", Title: "NotFound", Status: 404, Instance: "/users/1", Extensions: []*Extension{Ext("id", 1)}}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(problem *Problem)
+		want   []string
+	}{
+		{"valid", func(problem *Problem) {}, nil},
+		{"relative type", func(problem *Problem) { problem.Type = "/NotFound.html" }, nil},
+		{"status too low", func(problem *Problem) { problem.Status = 99 }, []string{"status 99 is not an HTTP status code"}},
+		{"status too high", func(problem *Problem) { problem.Status = 600 }, []string{"status 600 is not an HTTP status code"}},
+		{"type", func(problem *Problem) { problem.Type = "http://example.com/%zz" }, []string{`type "http://example.com/%zz" is not a URI reference`}},
+		{"instance", func(problem *Problem) { problem.Instance = "/users/%zz" }, []string{`instance "/users/%zz" is not a URI reference`}},
+		{"reserved extension", func(problem *Problem) { problem.Extensions = append(problem.Extensions, Ext("title", "x")) }, []string{`extension "title" collides with a standard member`}},
+		{"every violation", func(problem *Problem) {
+			problem.Status = 0
+			problem.Type = "%zz"
+			problem.Instance = "%zz"
+			problem.Extensions = []*Extension{Ext("status", 1), Ext("detail", "")}
+		}, []string{
+			"status 0 is not an HTTP status code",
+			`type "%zz" is not a URI reference`,
+			`instance "%zz" is not a URI reference`,
+			`extension "status" collides with a standard member`,
+			`extension "detail" collides with a standard member`,
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problem := valid()
+			test.modify(problem)
+
+			err := problem.Validate()
+			if test.want == nil {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if want := "rfc7807: invalid problem: " + strings.Join(test.want, "; "); err == nil || err.Error() != want {
+				t.Errorf("Validate() = %v, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestEncodeNDJSON(t *testing.T) {
+	problems := New("http://example.com/errors")
+	first := &Problem{Type: "http://example.com/errors/NotFound.html", Title: "NotFound", Status: 404, Detail: "line\nbreak", Extensions: []*Extension{Ext("id", 7)}}
+	second := &Problem{Title: "Gone", Status: 410}
+
+	var buf bytes.Buffer
+	if err := problems.EncodeNDJSON(&buf, first, second); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"http://example.com/errors/NotFound.html","title":"NotFound","status":404,"detail":"line\nbreak","id":7}` + "\n" +
+		`{"title":"Gone","status":410,"detail":""}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("EncodeNDJSON() wrote %s, want %s", got, want)
+	}
+
+	buf.Reset()
+	if err := problems.EncodeNDJSON(&buf); err != nil || buf.Len() != 0 {
+		t.Errorf("EncodeNDJSON() without problems = %v, wrote %q", err, buf.String())
+	}
+
+	buf.Reset()
+	broken := &Problem{Title: "Broken", Status: 500, Extensions: []*Extension{Ext("ch", make(chan int))}}
+	if err := problems.EncodeNDJSON(&buf, second, broken, first); err == nil || buf.String() != `{"title":"Gone","status":410,"detail":""}`+"\n" {
+		t.Errorf("EncodeNDJSON() = %v, wrote %q, want an error after the first line", err, buf.String())
+	}
+}
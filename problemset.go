@@ -0,0 +1,122 @@
+package rfc7807
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ProblemSet collects the problems of the failed items of a batch request, for
+// a single 207 Multi-Status problem. It is safe for concurrent use.
+type ProblemSet struct {
+	rfc7807  *RFC7807
+	mutex    sync.Mutex
+	problems []indexedProblem
+}
+
+type indexedProblem struct {
+	index   int
+	problem *Problem
+}
+
+// ProblemSet returns an empty ProblemSet written with rfc7807.
+func (rfc7807 *RFC7807) ProblemSet() *ProblemSet {
+	return &ProblemSet{rfc7807: rfc7807}
+}
+
+// Add records problem as the outcome of the item at index.
+func (set *ProblemSet) Add(index int, problem *Problem) {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	set.problems = append(set.problems, indexedProblem{index: index, problem: problem})
+}
+
+// Len returns the number of problems added.
+func (set *ProblemSet) Len() int {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	return len(set.problems)
+}
+
+// WriteMultiStatus writes a 207 problem listing the problems added, ordered by
+// index, in an "errors" extension:
+//
+//	"errors": [{"index": 2, "problem": {"title": "Not Found", ...}}]
+func (set *ProblemSet) WriteMultiStatus(w http.ResponseWriter) int {
+	set.mutex.Lock()
+	problems := append([]indexedProblem{}, set.problems...)
+	set.mutex.Unlock()
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].index < problems[j].index
+	})
+
+	errors := make([]extensionObject, 0, len(problems))
+	for _, p := range problems {
+		errors = append(errors, extensionObject{Ext("index", p.index), Ext("problem", p.problem)})
+	}
+
+	detail := fmt.Sprintf("%d items failed.", len(problems))
+	return set.rfc7807.error(w, nil, "", http.StatusMultiStatus, detail, []*Extension{Ext("errors", errors)})
+}
+
+// ErrorMany writes problems as a top-level JSON array with status, for clients
+// that expect independent problems rather than one problem listing them. Each
+// element is flushed as it is written under WithIncrementalFlush. Like Error, it
+// returns the status written, or 0 when a writer from Wrap had already committed
+// the response.
+func (rfc7807 *RFC7807) ErrorMany(w http.ResponseWriter, status int, problems ...*Problem) int {
+	status = rfc7807.clampStatus(status)
+	if guard, ok := w.(*guardWriter); ok && guard.committed {
+		rfc7807.logf("rfc7807: response already committed, dropped %d problems (%d)", len(problems), status)
+		return 0
+	}
+
+	indent := rfc7807.indent(nil)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte{'['})
+
+	first := true
+	for _, problem := range problems {
+		if problem == nil {
+			continue
+		}
+		problem = rfc7807.finalize(problem, nil)
+		rfc7807.report(problem, nil)
+
+		element, err := json.Marshal(problem)
+		if err != nil {
+			rfc7807.logf("rfc7807: failed to encode problem %q: %v", problem.Title, err)
+			continue
+		}
+
+		buf := bytes.NewBuffer(make([]byte, 0, 2*len(element)+len(indent)+2))
+		if !first {
+			buf.WriteByte(',')
+		}
+		if indent != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(indent)
+			json.Indent(buf, element, indent, indent)
+		} else {
+			buf.Write(element)
+		}
+		first = false
+
+		w.Write(buf.Bytes())
+		rfc7807.flush(w)
+	}
+
+	if indent != "" && !first {
+		w.Write([]byte{'\n'})
+	}
+	w.Write([]byte("]\n"))
+	rfc7807.flush(w)
+	return status
+}
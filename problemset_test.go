@@ -0,0 +1,131 @@
+package rfc7807
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestProblemSet(t *testing.T) {
+	problems := New("http://example.com/errors", WithPrettyQueryParam("pretty"))
+
+	tests := []struct {
+		name   string
+		failed map[int]string
+		want   string
+	}{
+		{"mixed", map[int]string{3: "Out of Stock", 1: "Not Found"}, `{"title":"Multi-Status","status":207,"detail":"2 items failed.","errors":[{"index":1,"problem":{"title":"Not Found","status":404,"detail":"item 1"}},{"index":3,"problem":{"title":"Out of Stock","status":404,"detail":"item 3"}}]}`},
+		{"all succeeded", nil, `{"title":"Multi-Status","status":207,"detail":"0 items failed.","errors":[]}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set := problems.ProblemSet()
+
+			// Process five items concurrently; the failed ones add a problem.
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					if title, failed := test.failed[i]; failed {
+						set.Add(i, &Problem{Title: title, Status: 404, Detail: fmt.Sprint("item ", i)})
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			if set.Len() != len(test.failed) {
+				t.Errorf("Len() = %d, want %d", set.Len(), len(test.failed))
+			}
+
+			w := httptest.NewRecorder()
+			if got := set.WriteMultiStatus(w); got != 207 || w.Code != 207 {
+				t.Errorf("WriteMultiStatus() = %d, status %d, want 207", got, w.Code)
+			}
+			if got := w.Body.String(); got != test.want+"\n" {
+				t.Errorf("got  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestErrorMany(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		problems []*Problem
+		want     string
+	}{
+		{
+			name:    "compact",
+			options: []Option{WithPrettyQueryParam("pretty")},
+			problems: []*Problem{
+				{Title: "Invalid Name", Status: 422, Detail: "is required", Extensions: []*Extension{Ext("pointer", "/name")}},
+				nil,
+				{Type: "http://example.com/errors/Age.html", Title: "Invalid Age", Status: 422, Instance: "/users/1"},
+			},
+			want: `[{"title":"Invalid Name","status":422,"detail":"is required","pointer":"/name"},{"type":"http://example.com/errors/Age.html","title":"Invalid Age","status":422,"detail":"","instance":"/users/1"}]` + "\n",
+		},
+		{
+			name:     "indented",
+			problems: []*Problem{{Title: "Invalid Name", Status: 422}, {Title: "Invalid Age", Status: 422}},
+			want:     "[\n  {\n    \"title\": \"Invalid Name\",\n    \"status\": 422,\n    \"detail\": \"\"\n  },\n  {\n    \"title\": \"Invalid Age\",\n    \"status\": 422,\n    \"detail\": \"\"\n  }\n]\n",
+		},
+		{
+			name:    "empty",
+			options: []Option{WithPrettyQueryParam("pretty")},
+			want:    "[]\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if got := New("http://example.com/errors", test.options...).ErrorMany(w, 422, test.problems...); got != 422 {
+				t.Errorf("ErrorMany() = %d, want 422", got)
+			}
+			if w.Code != 422 || w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+				t.Errorf("response = %d %q, want a 422 JSON array", w.Code, w.Header().Get("Content-Type"))
+			}
+			if w.Body.String() != test.want {
+				t.Errorf("got  %q\nwant %q", w.Body.String(), test.want)
+			}
+
+			elements := []map[string]interface{}{}
+			if err := json.Unmarshal(w.Body.Bytes(), &elements); err != nil {
+				t.Fatalf("invalid array %q: %v", w.Body.String(), err)
+			}
+			for i, problem := range elements {
+				if problem["status"] != float64(422) || problem["title"] == "" {
+					t.Errorf("element %d = %v, want a problem", i, problem)
+				}
+			}
+		})
+	}
+
+	t.Run("clamped", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		if got := New("http://example.com/errors", WithLogger(log.New(ioutil.Discard, "", 0))).ErrorMany(w, 42); got != 500 || w.Code != 500 {
+			t.Errorf("ErrorMany() = %d, status %d, want 500", got, w.Code)
+		}
+	})
+
+	t.Run("committed", func(t *testing.T) {
+		problems := New("http://example.com/errors", WithLogger(log.New(ioutil.Discard, "", 0)))
+		recorder := httptest.NewRecorder()
+		w := problems.Wrap(recorder)
+		w.Write([]byte("partial"))
+
+		if got := problems.ErrorMany(w, 422, &Problem{Title: "Invalid Name", Status: 422}); got != 0 {
+			t.Errorf("ErrorMany() = %d, want 0", got)
+		}
+		if recorder.Body.String() != "partial" {
+			t.Errorf("body = %q, want it untouched", recorder.Body.String())
+		}
+	})
+}
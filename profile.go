@@ -0,0 +1,24 @@
+package rfc7807
+
+import "fmt"
+
+// Profile serves html as the doc page of the profile name, a group of related
+// problems such as "billing", at /_profiles/<name>.html. Problems registered
+// with the returned DocOption carry a "profile" member linking to that page.
+func (rfc7807 *RFC7807) Profile(name string, html []byte) DocOption {
+	profile := &doc{title: name, path: "/_profiles/" + rfc7807.escape(name) + ".html", html: html}
+
+	rfc7807.registry.Lock()
+	profile.url = rfc7807.typeURL(profile.path)
+	if err := checkPattern(routePath(profile.path)); err != nil {
+		rfc7807.errs = append(rfc7807.errs, fmt.Errorf("%v for profile %q", err, name))
+	} else {
+		rfc7807.serveDoc(profile)
+		rfc7807.profiles = append(rfc7807.profiles, profile)
+	}
+	rfc7807.registry.Unlock()
+
+	return func(doc *doc) {
+		doc.profile = profile
+	}
+}
@@ -0,0 +1,51 @@
+package rfc7807
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	problems := New("http://example.com/errors")
+	billing := problems.Profile("billing", []byte("<h1>Billing</h1>"))
+	auth := problems.Profile("auth", []byte("<h1>Auth</h1>"))
+	if _, err := problems.Doc("OutOfCredit", "", billing); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.Doc("CardDeclined", "", billing); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.Doc("TokenExpired", "", auth); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.Doc("NotFound", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		title   string
+		profile interface{}
+	}{
+		{"OutOfCredit", "http://example.com/errors/_profiles/billing.html"},
+		{"CardDeclined", "http://example.com/errors/_profiles/billing.html"},
+		{"TokenExpired", "http://example.com/errors/_profiles/auth.html"},
+		{"NotFound", nil},
+		{"Unregistered", nil},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		problems.Error(w, test.title, 400, "")
+		if got := decode(t, w)["profile"]; got != test.profile {
+			t.Errorf("profile of %s = %v, want %v", test.title, got, test.profile)
+		}
+	}
+
+	if page := serve(problems, http.MethodGet, "/_profiles/billing.html", nil); page.Code != http.StatusOK || page.Body.String() != "<h1>Billing</h1>" {
+		t.Errorf("profile page = %d %q", page.Code, page.Body.String())
+	}
+	if errs := problems.Verify(); len(errs) != 0 {
+		t.Errorf("Verify() = %v", errs)
+	}
+}
@@ -0,0 +1,31 @@
+package rfc7807
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPlaceholder is replaced by the retry duration in the detail passed to
+// TooManyRequests.
+const RetryPlaceholder = "{retry}"
+
+// TooManyRequests writes a 429 problem with a Retry-After header. Any
+// RetryPlaceholder in detail is replaced by the same duration, rounded up to
+// whole seconds, so header and body always agree. An empty detail defaults to
+// "Rate limit exceeded; retry in {retry}".
+func (rfc7807 *RFC7807) TooManyRequests(w http.ResponseWriter, retryAfter time.Duration, detail string, extensions ...*Extension) int {
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	if detail == "" {
+		detail = "Rate limit exceeded; retry in " + RetryPlaceholder
+	}
+	detail = strings.Replace(detail, RetryPlaceholder, (time.Duration(seconds) * time.Second).String(), -1)
+
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	return rfc7807.error(w, nil, "", http.StatusTooManyRequests, detail, extensions)
+}
@@ -0,0 +1,41 @@
+package rfc7807
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTooManyRequests(t *testing.T) {
+	problems := New("http://example.com/errors")
+
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		detail     string
+		header     string
+		want       string
+	}{
+		{"default detail", 3 * time.Second, "", "3", "Rate limit exceeded; retry in 3s"},
+		{"rounded up", 1500 * time.Millisecond, "", "2", "Rate limit exceeded; retry in 2s"},
+		{"just over", 60*time.Second + time.Nanosecond, "", "61", "Rate limit exceeded; retry in 1m1s"},
+		{"negative", -time.Second, "", "0", "Rate limit exceeded; retry in 0s"},
+		{"placeholders", 90 * time.Second, "Wait {retry}. Really, {retry}.", "90", "Wait 1m30s. Really, 1m30s."},
+		{"no placeholder", time.Second, "Slow down.", "1", "Slow down."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			problems.TooManyRequests(w, test.retryAfter, test.detail)
+
+			if got := w.Header().Get("Retry-After"); got != test.header {
+				t.Errorf("Retry-After = %q, want %q", got, test.header)
+			}
+			problem := decode(t, w)
+			if w.Code != 429 || problem["title"] != "Too Many Requests" || problem["detail"] != test.want {
+				t.Errorf("problem = %d %v, want 429 with detail %q", w.Code, problem, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package rfc7807
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ProblemReporter receives every 5xx problem written, e.g. to forward it to an
+// error aggregator such as Sentry. r is nil for problems written without a
+// request, as by Error.
+type ProblemReporter interface {
+	Report(p *Problem, r *http.Request)
+}
+
+// WithReporter reports 5xx problems to reporter before they are written.
+func WithReporter(reporter ProblemReporter) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.reporter = reporter
+	}
+}
+
+// WithAsyncReporter is like WithReporter, but reports from a goroutine through
+// a queue of size buffer, so slow reporters do not delay responses. Problems
+// arriving while the queue is full are dropped with a logged warning. Close
+// drains the queue.
+func WithAsyncReporter(reporter ProblemReporter, buffer int) Option {
+	return func(rfc7807 *RFC7807) {
+		async := &asyncReporter{
+			rfc7807:  rfc7807,
+			reporter: reporter,
+			queue:    make(chan report, buffer),
+			done:     make(chan struct{}),
+		}
+		go async.run()
+
+		rfc7807.reporter = async
+		rfc7807.closers = append(rfc7807.closers, async.close)
+	}
+}
+
+// report passes problem to the reporter if it is a server error.
+func (rfc7807 *RFC7807) report(problem *Problem, r *http.Request) {
+	if rfc7807.reporter != nil && problem.Status >= 500 {
+		rfc7807.reporter.Report(problem, r)
+	}
+}
+
+type report struct {
+	problem *Problem
+	request *http.Request
+}
+
+type asyncReporter struct {
+	rfc7807  *RFC7807
+	reporter ProblemReporter
+	queue    chan report
+	done     chan struct{}
+
+	mutex  sync.Mutex
+	closed bool
+}
+
+func (async *asyncReporter) Report(p *Problem, r *http.Request) {
+	async.mutex.Lock()
+	defer async.mutex.Unlock()
+
+	if async.closed {
+		return
+	}
+
+	select {
+	case async.queue <- report{problem: p, request: r}:
+	default:
+		async.rfc7807.logf("rfc7807: report queue full, dropped %q", p.Title)
+	}
+}
+
+func (async *asyncReporter) run() {
+	defer close(async.done)
+	for report := range async.queue {
+		async.reporter.Report(report.problem, report.request)
+	}
+}
+
+// close stops accepting reports and waits for the queued ones until ctx is done.
+func (async *asyncReporter) close(ctx context.Context) error {
+	async.mutex.Lock()
+	if !async.closed {
+		async.closed = true
+		close(async.queue)
+	}
+	async.mutex.Unlock()
+
+	select {
+	case <-async.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("rfc7807: %d reports not flushed: %w", len(async.queue), ctx.Err())
+	}
+}
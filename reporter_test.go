@@ -0,0 +1,211 @@
+package rfc7807
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingReporter records the titles of the problems reported.
+type recordingReporter struct {
+	mutex  sync.Mutex
+	titles []string
+}
+
+func (reporter *recordingReporter) Report(p *Problem, r *http.Request) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+
+	reporter.titles = append(reporter.titles, p.Title)
+}
+
+func (reporter *recordingReporter) reported() []string {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+
+	return append([]string(nil), reporter.titles...)
+}
+
+func TestWithReporter(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(problems *RFC7807)
+		want  []string
+	}{
+		{
+			name: "server error",
+			write: func(problems *RFC7807) {
+				problems.Error(httptest.NewRecorder(), "Database Down", 503, "")
+			},
+			want: []string{"Database Down"},
+		},
+		{
+			name: "client error",
+			write: func(problems *RFC7807) {
+				problems.Error(httptest.NewRecorder(), "Not Found", 404, "")
+			},
+		},
+		{
+			name: "undocumented server error",
+			write: func(problems *RFC7807) {
+				problems.Error(httptest.NewRecorder(), "", 500, "")
+			},
+			want: []string{"Internal Server Error"},
+		},
+		{
+			name: "browser",
+			write: func(problems *RFC7807) {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.Header.Set("Accept", "text/html")
+				problems.ErrorRequest(httptest.NewRecorder(), r, "Database Down", 503, "")
+			},
+			want: []string{"Database Down"},
+		},
+		{
+			name: "WriteProblem",
+			write: func(problems *RFC7807) {
+				problems.WriteProblem(httptest.NewRecorder(), nil, problems.GRPCProblem(13, "internal"))
+				problems.WriteProblem(httptest.NewRecorder(), nil, problems.GRPCProblem(5, "not found"))
+			},
+			want: []string{"Internal Server Error"},
+		},
+		{
+			name: "ErrorMany",
+			write: func(problems *RFC7807) {
+				problems.ErrorMany(httptest.NewRecorder(), 500,
+					&Problem{Title: "Database Down", Status: 503},
+					&Problem{Title: "Invalid Name", Status: 422},
+				)
+			},
+			want: []string{"Database Down"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reporter := &recordingReporter{}
+			test.write(New("http://example.com/errors", WithReporter(reporter)))
+
+			if got := reporter.reported(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("reported %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// gatedReporter records problems once gate is closed, like a reporter stuck on
+// a slow sink until then.
+type gatedReporter struct {
+	recordingReporter
+	gate chan struct{}
+}
+
+func (reporter *gatedReporter) Report(p *Problem, r *http.Request) {
+	<-reporter.gate
+	reporter.recordingReporter.Report(p, r)
+}
+
+func TestWithAsyncReporterClose(t *testing.T) {
+	t.Run("flush", func(t *testing.T) {
+		reporter := &gatedReporter{gate: make(chan struct{})}
+		problems := New("http://example.com/errors", WithAsyncReporter(reporter, 8))
+		for _, title := range []string{"First", "Second", "Third"} {
+			problems.Error(httptest.NewRecorder(), title, 500, "")
+		}
+		if got := reporter.reported(); len(got) != 0 {
+			t.Fatalf("reported %v before the sink was ready", got)
+		}
+
+		close(reporter.gate)
+		if err := problems.Close(context.Background()); err != nil {
+			t.Fatalf("Close() = %v", err)
+		}
+		if got, want := reporter.reported(), []string{"First", "Second", "Third"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("reported %v, want %v", got, want)
+		}
+
+		problems.Error(httptest.NewRecorder(), "Late", 500, "")
+		if err := problems.Close(context.Background()); err != nil {
+			t.Errorf("second Close() = %v", err)
+		}
+		if got := reporter.reported(); len(got) != 3 {
+			t.Errorf("reported %v after Close", got)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		reporter := &gatedReporter{gate: make(chan struct{})}
+		defer close(reporter.gate)
+		problems := New("http://example.com/errors", WithAsyncReporter(reporter, 8))
+		problems.Error(httptest.NewRecorder(), "First", 500, "")
+		problems.Error(httptest.NewRecorder(), "Second", 500, "")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := problems.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Close() = %v, want the deadline", err)
+		}
+	})
+
+	t.Run("no async hooks", func(t *testing.T) {
+		if err := New("http://example.com/errors", WithReporter(&recordingReporter{})).Close(context.Background()); err != nil {
+			t.Errorf("Close() = %v", err)
+		}
+	})
+}
+
+// encodingReporter records the problems reported, encoded as JSON.
+type encodingReporter struct {
+	recordingReporter
+	encoded []string
+}
+
+func (reporter *encodingReporter) Report(p *Problem, r *http.Request) {
+	body, _ := json.Marshal(p)
+
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+
+	reporter.encoded = append(reporter.encoded, string(body))
+}
+
+func TestWithAsyncReporterFinalizer(t *testing.T) {
+	annotate := func(problem *Problem, r *http.Request) {
+		problem.Detail += " (annotated)"
+		for _, extension := range problem.Extensions {
+			extension.Key = strings.ToUpper(extension.Key)
+		}
+	}
+	reporter := &encodingReporter{}
+	problems := New("http://example.com/errors", WithAsyncReporter(reporter, 8), WithFinalizer(annotate))
+
+	problem := &Problem{Title: "Broken", Status: 500, Detail: "down", Extensions: []*Extension{Ext("code", "E1")}}
+	problems.Error(httptest.NewRecorder(), "Broken", 500, "down", Ext("code", "E1"))
+	problems.WriteProblem(httptest.NewRecorder(), nil, problem)
+	problems.ErrorMany(httptest.NewRecorder(), 500, problem)
+	if err := problems.Close(context.Background()); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	if problem.Detail != "down" || problem.Extensions[0].Key != "code" {
+		t.Errorf("problem = %+v, want it left as is", problem)
+	}
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+	want := `{"title":"Broken","status":500,"detail":"down (annotated)","CODE":"E1"}`
+	if len(reporter.encoded) != 3 {
+		t.Fatalf("reported %q, want three problems", reporter.encoded)
+	}
+	for _, encoded := range reporter.encoded {
+		if !strings.HasSuffix(encoded, want[1:]) {
+			t.Errorf("reported %s, want the finalized %s", encoded, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package rfc7807
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// ProblemResponse returns the response Error would write, as a client would
+// receive it. It is meant for testing client code without a server.
+func (rfc7807 *RFC7807) ProblemResponse(title string, status int, detail string, extensions ...*Extension) *http.Response {
+	recorder := &responseRecorder{header: http.Header{}}
+	rfc7807.error(recorder, nil, title, status, detail, extensions)
+
+	recorder.header.Set("Content-Length", strconv.Itoa(recorder.body.Len()))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorder.status, http.StatusText(recorder.status)),
+		StatusCode:    recorder.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorder.header,
+		Body:          ioutil.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+		ContentLength: int64(recorder.body.Len()),
+	}
+}
+
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (recorder *responseRecorder) Header() http.Header {
+	return recorder.header
+}
+
+func (recorder *responseRecorder) WriteHeader(status int) {
+	if recorder.wroteHeader {
+		return
+	}
+	recorder.wroteHeader = true
+	recorder.status = status
+}
+
+func (recorder *responseRecorder) Write(b []byte) (int, error) {
+	if !recorder.wroteHeader {
+		recorder.WriteHeader(http.StatusOK)
+	}
+
+	return recorder.body.Write(b)
+}
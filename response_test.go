@@ -0,0 +1,44 @@
+package rfc7807
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestProblemResponse(t *testing.T) {
+	problems := New("http://example.com/errors", WithPrettyQueryParam("pretty"))
+	if _, err := problems.Doc("OutOfCredit", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	response := problems.ProblemResponse("OutOfCredit", 403, "low", Ext("balance", 30))
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusForbidden || response.Status != "403 Forbidden" || response.Proto != "HTTP/1.1" {
+		t.Errorf("response = %d %q %s, want 403 Forbidden over HTTP/1.1", response.StatusCode, response.Status, response.Proto)
+	}
+	if got := response.Header.Get("Content-Type"); got != "application/problem+json; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ContentLength != int64(len(body)) || response.Header.Get("Content-Length") != strconv.Itoa(len(body)) {
+		t.Errorf("Content-Length = %d / %q, want %d", response.ContentLength, response.Header.Get("Content-Length"), len(body))
+	}
+
+	var problem map[string]interface{}
+	if err := json.Unmarshal(body, &problem); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"type": "http://example.com/errors/OutOfCredit.html", "title": "OutOfCredit", "status": float64(403), "detail": "low", "balance": float64(30)}
+	if !reflect.DeepEqual(problem, want) {
+		t.Errorf("problem = %v, want %v", problem, want)
+	}
+}
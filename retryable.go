@@ -0,0 +1,20 @@
+package rfc7807
+
+// DefaultRetryableKey is the member name of Retryable extensions, unless
+// changed with WithRetryableKey.
+const DefaultRetryableKey = "retryable"
+
+type retryable bool
+
+// Retryable returns an extension telling clients whether retrying the request
+// may succeed. On 429 and 503 problems it complements the Retry-After header.
+func Retryable(ok bool) *Extension {
+	return Ext(DefaultRetryableKey, retryable(ok))
+}
+
+// WithRetryableKey changes the member name of Retryable extensions.
+func WithRetryableKey(key string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.retryableKey = key
+	}
+}
@@ -0,0 +1,39 @@
+package rfc7807
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   []Option
+		extension *Extension
+		key       string
+		want      interface{}
+	}{
+		{"true", nil, Retryable(true), "retryable", true},
+		{"false", nil, Retryable(false), "retryable", false},
+		{"custom key", []Option{WithRetryableKey("canRetry")}, Retryable(true), "canRetry", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", test.options...)
+			w := httptest.NewRecorder()
+			problems.Error(w, "", 503, "", test.extension)
+
+			problem := decode(t, w)
+			value, ok := problem[test.key].(bool)
+			if !ok || value != test.want {
+				t.Errorf("%s = %#v, want the boolean %v", test.key, problem[test.key], test.want)
+			}
+			if test.key != DefaultRetryableKey {
+				if _, ok := problem[DefaultRetryableKey]; ok {
+					t.Errorf("default key written next to %q", test.key)
+				}
+			}
+		})
+	}
+}
@@ -2,29 +2,122 @@ package rfc7807
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"log"
 	"net/http"
 	"net/url"
-	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/pressly/chi"
 	"github.com/russross/blackfriday"
 )
 
-func New(url string) *RFC7807 {
-	return &RFC7807{
-		URL:             url,
-		mux:             chi.NewMux(),
-		problemHandlers: map[string]problemHandlerFunc{},
+// New returns an RFC7807 whose doc pages live under baseURL. An invalid baseURL
+// is reported by Verify; type URLs then fall back to relative paths.
+func New(baseURL string, options ...Option) *RFC7807 {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		u = nil
 	}
+
+	return newRFC7807(baseURL, u, options)
+}
+
+// NewURL is like New, but takes an already parsed base URL.
+func NewURL(u *url.URL, options ...Option) *RFC7807 {
+	baseURL := ""
+	if u != nil {
+		baseURL = u.String()
+	}
+
+	return newRFC7807(baseURL, u, options)
+}
+
+func newRFC7807(baseURL string, u *url.URL, options []Option) *RFC7807 {
+	rfc7807 := &RFC7807{
+		URL:     baseURL,
+		baseURL: u,
+		docs:    map[string]*doc{},
+	}
+	rfc7807.mux = rfc7807.newMux()
+
+	for _, option := range options {
+		option(rfc7807)
+	}
+
+	return rfc7807
 }
 
 type RFC7807 struct {
-	URL             string
-	mux             *chi.Mux
-	problemHandlers map[string]problemHandlerFunc
+	URL                string
+	baseURL            *url.URL
+	mux                *chi.Mux
+	docs               map[string]*doc
+	registry           sync.RWMutex
+	nestedExtensions   string
+	namespace          string
+	prettyQueryParam   string
+	fallbackDoc        *doc
+	lazyDocs           bool
+	contentLocation    bool
+	iriTypes           bool
+	debug              bool
+	grpcCodes          map[uint32]grpcMapping
+	defaultType        string
+	linkHeader         bool
+	instanceBase       string
+	docVariant         func(*http.Request) string
+	unprocessableTitle string
+	singleDocPage      string
+	requestIDHeader    string
+	requestIDMember    string
+	logger             *log.Logger
+	incrementalFlush   bool
+	docHead            string
+	extensionEncoders  map[reflect.Type]func(interface{}) interface{}
+	withoutType        bool
+	slugger            func(string) string
+	slugSuffixes       bool
+	routes             map[string]string
+	errs               []error
+	cors               *CORS
+	reporter           ProblemReporter
+	instanceClasses    map[int]bool
+	retryableKey       string
+	dynamicBaseURL     func(*http.Request) string
+	strictTitles       bool
+	precompressedDocs  bool
+	derivedDetailKey   string
+	derivedDetail      string
+	docVariantVary     []string
+	sortedKeys         bool
+	marshalers         map[string]Marshaler
+	maxExtensions      int
+	trailers           []trailer
+	docLayout          *template.Template
+	closers            []func(context.Context) error
+	codes              map[string]string
+	pathEscaper        func(string) string
+	errorFormat        ErrorFormat
+	finalizers         []func(*Problem, *http.Request)
+	generateInstance   bool
+	instancePrefix     string
+	uuidGenerator      func() string
+	adaptiveIndent     int
+	statusTitles       map[int]string
+	keyCase            KeyCase
+	envelope           func(json.RawMessage) interface{}
+	validateDocs       bool
+	profiles           []*doc
 }
 
 type problemHandlerFunc func(http.ResponseWriter, int, string, ...*Extension)
@@ -38,119 +131,1051 @@ func Ext(key string, value interface{}) *Extension {
 	return &Extension{Key: key, Value: value}
 }
 
+// Instance returns an extension that sets the instance member of the problem.
+func Instance(uri string) *Extension {
+	return Ext("instance", uri)
+}
+
+// ExtValues returns an extension holding values as an object sorted by key, e.g.
+// the offending query parameters of a request, as {"limit": ["-1"]}.
+func ExtValues(key string, values url.Values) *Extension {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	object := make(extensionObject, 0, len(keys))
+	for _, k := range keys {
+		object = append(object, Ext(k, values[k]))
+	}
+
+	return Ext(key, object)
+}
+
+// lazyValue is the value of ExtFunc extensions.
+type lazyValue func() interface{}
+
+// ExtFunc returns an extension whose value fn computes when a problem carrying
+// it is built, once per problem, for values too costly to compute up front.
+func ExtFunc(key string, fn func() interface{}) *Extension {
+	return Ext(key, lazyValue(fn))
+}
+
+// debugBytes is the value of ExtBytes extensions.
+type debugBytes []byte
+
+// ExtBytes returns an extension holding b as a standard base64 string, e.g. a
+// request fingerprint for support. It is written in debug mode only.
+func ExtBytes(key string, b []byte) *Extension {
+	return Ext(key, debugBytes(b))
+}
+
 var DefaultTemplate = `<html>
   <head>
     <meta charset="utf-8">
     <title>Error {{.Title}}</title>
   </head>
   <body>
-    <h1>{{.Title}}</h1>
-    <pre>{{.Description}}</pre>
+    <h1>{{.Title}}</h1>{{if .Detail}}
+    <p class="problem-detail">{{.Detail}}</p>{{end}}{{if .Description}}
+    <pre>{{.Description}}</pre>{{end}}{{if .Fields}}
+    <table>
+      <tr><th>Member</th><th>Description</th></tr>{{range .Fields}}
+      <tr><td><code>{{.Name}}</code></td><td>{{.Description}}</td></tr>{{end}}
+    </table>{{end}}
   </body>
 </html>`
 
-func (rfc7807 *RFC7807) Doc(title, description string) (problemHandlerFunc, error) {
-	return rfc7807.TemplateDoc(title, description, DefaultTemplate)
+func (rfc7807 *RFC7807) Doc(title, description string, options ...DocOption) (problemHandlerFunc, error) {
+	return rfc7807.templateDoc(title, description, DefaultTemplate, template.FuncMap{}, true, options)
+}
+
+func (rfc7807 *RFC7807) TemplateDoc(title string, description string, templateStr string, options ...DocOption) (problemHandlerFunc, error) {
+	return rfc7807.TemplateDocFuncs(title, description, templateStr, template.FuncMap{}, options...)
+}
+
+func (rfc7807 *RFC7807) TemplateDocFuncs(title string, description string, templateStr string, funcs template.FuncMap, options ...DocOption) (problemHandlerFunc, error) {
+	return rfc7807.templateDoc(title, description, templateStr, funcs, false, options)
 }
 
-func (rfc7807 *RFC7807) TemplateDoc(title string, description string, templateStr string) (problemHandlerFunc, error) {
-	template, tError := template.New("default.tpl").Parse(templateStr)
+// templateDoc registers a template doc. Generated pages are styled with the
+// WithDocStylesheet family, while user templates are left as they are.
+func (rfc7807 *RFC7807) templateDoc(title string, description string, templateStr string, funcs template.FuncMap, styled bool, options []DocOption) (problemHandlerFunc, error) {
+	template, tError := template.New(title).Funcs(funcs).Parse(templateStr)
 	if tError != nil {
-		return nil, tError
+		return nil, fmt.Errorf("rfc7807: failed to parse template for %q: %w", title, tError)
 	}
 
-	buf := bytes.NewBuffer(make([]byte, 0, 1024))
-	if err := template.Execute(buf, map[string]string{"Title": title, "Description": description}); err != nil {
+	execute := func(data map[string]interface{}) ([]byte, error) {
+		buf := bytes.NewBuffer(make([]byte, 0, 1024))
+		if err := template.Execute(buf, data); err != nil {
+			return nil, fmt.Errorf("rfc7807: failed to execute template for %q: %w", title, err)
+		}
+		if rfc7807.docLayout != nil {
+			return rfc7807.layout(title, buf.Bytes())
+		}
+		if styled {
+			return rfc7807.styled(buf.Bytes()), nil
+		}
+		return buf.Bytes(), nil
+	}
+
+	fields := docFields(options)
+
+	bake := func() ([]byte, error) {
+		return execute(map[string]interface{}{"Title": title, "Description": description, "Fields": fields})
+	}
+
+	page := func(status int, detail string) ([]byte, error) {
+		return execute(map[string]interface{}{"Title": title, "Description": description, "Fields": fields, "Status": status, "Detail": detail})
+	}
+
+	return rfc7807.bakedDoc(title, bake, page, options)
+}
+
+func (rfc7807 *RFC7807) MarkdownDoc(title string, markdown []byte, options ...DocOption) problemHandlerFunc {
+	bake := func() ([]byte, error) {
+		if rfc7807.docLayout != nil {
+			return rfc7807.layout(title, blackfriday.MarkdownCommon([]byte(markdown)))
+		}
+
+		buf := bytes.NewBuffer(make([]byte, 0, 1024))
+		buf.WriteString("<html>\n<head>\n  <meta charset=\"utf-8\">\n  <title>Error ")
+		buf.WriteString(template.HTMLEscapeString(title))
+		buf.WriteString("</title>\n")
+		buf.WriteString(rfc7807.docHead)
+		buf.WriteString("</head>\n<body>\n")
+		buf.Write(blackfriday.MarkdownCommon([]byte(markdown)))
+		buf.WriteString("</body>\n</html>")
+		return buf.Bytes(), nil
+	}
+
+	handler, _ := rfc7807.bakedDoc(title, bake, nil, options)
+	return handler
+}
+
+func (rfc7807 *RFC7807) HtmlDoc(title string, html []byte, options ...DocOption) problemHandlerFunc {
+	d := &doc{title: title, html: html}
+	if rfc7807.precompressedDocs {
+		d.gzipped = gzipBytes(html)
+	}
+
+	handler, _ := rfc7807.register(d, options)
+	return handler
+}
+
+// ExternalDoc registers title with its type set verbatim to typeURL, for
+// problems documented elsewhere, e.g. by a standards body. No page is served.
+// typeURL may be a fragment-only reference such as "#/problems/not-found" into
+// an API doc bundle; it is not resolved against the base URL.
+func (rfc7807 *RFC7807) ExternalDoc(title string, typeURL string, options ...DocOption) problemHandlerFunc {
+	handler, _ := rfc7807.register(&doc{title: title, url: typeURL, external: true}, options)
+	return handler
+}
+
+// HtmlDocVariants registers audience-specific doc pages for title, keyed by
+// variant name. The doc route serves the variant chosen by the
+// WithDocVariantSelector function, falling back to the "public" variant.
+func (rfc7807 *RFC7807) HtmlDocVariants(title string, variants map[string][]byte, options ...DocOption) problemHandlerFunc {
+	handler, _ := rfc7807.register(&doc{title: title, html: variants[publicVariant], variants: variants}, options)
+	return handler
+}
+
+// bakedDoc registers a doc whose HTML is produced by bake, either right away or,
+// with WithLazyDocs, on the first request for the doc page.
+func (rfc7807 *RFC7807) bakedDoc(title string, bake func() ([]byte, error), page func(int, string) ([]byte, error), options []DocOption) (problemHandlerFunc, error) {
+	if rfc7807.lazyDocs {
+		return rfc7807.register(&doc{title: title, bake: bake, page: page}, options)
+	}
+
+	html, err := bake()
+	if err != nil {
 		return nil, err
 	}
 
-	return rfc7807.HtmlDoc(title, buf.Bytes()), nil
+	return rfc7807.register(&doc{title: title, html: html, page: page}, options)
 }
 
-func (rfc7807 *RFC7807) MarkdownDoc(title string, markdown []byte) problemHandlerFunc {
-	buf := bytes.NewBuffer(make([]byte, 0, 1024))
-	buf.WriteString(`<html>\n<head>\n  <meta charset="utf-8">\n  <title>Error `)
-	buf.WriteString(title)
-	buf.WriteString(`</title>\n</head>\n<body>`)
-	buf.Write(blackfriday.MarkdownCommon([]byte(markdown)))
-	buf.WriteString(`</body>\n</html>`)
+// register adds d to the registry and serves its doc page, if it has one. When
+// the page collides with the page of another title, or is malformed under
+// WithValidateDocs, the doc is registered without a page and the error is also
+// reported by Verify.
+func (rfc7807 *RFC7807) register(d *doc, options []DocOption) (problemHandlerFunc, error) {
+	for _, option := range options {
+		option(d)
+	}
+
+	rfc7807.registry.Lock()
+	defer rfc7807.registry.Unlock()
+
+	var err error
+	if rfc7807.validateDocs {
+		err = validateDoc(d)
+	}
+	if err == nil && (d.bake != nil || len(d.html) > 0 || len(d.variants) > 0) {
+		err = rfc7807.route(d)
+	}
+	if err != nil {
+		rfc7807.errs = append(rfc7807.errs, err)
+	}
+
+	if rfc7807.docs == nil {
+		rfc7807.docs = map[string]*doc{}
+	}
+	rfc7807.docs[d.title] = d
+	if d.code != "" {
+		if rfc7807.codes == nil {
+			rfc7807.codes = map[string]string{}
+		}
+		rfc7807.codes[d.code] = d.title
+	}
 
-	return rfc7807.HtmlDoc(title, buf.Bytes())
+	return func(w http.ResponseWriter, status int, detail string, extensions ...*Extension) {
+		rfc7807.error(w, nil, d.title, status, detail, extensions)
+	}, err
 }
 
-func (rfc7807 *RFC7807) HtmlDoc(title string, html []byte) problemHandlerFunc {
+// route assigns the doc page of d from the slug of its title, appending "-2",
+// "-3", ... on collision when WithSlugSuffixes is set.
+func (rfc7807 *RFC7807) route(d *doc) error {
+	if rfc7807.routes == nil {
+		rfc7807.routes = map[string]string{}
+	}
+
+	slug := d.slug
+	if slug == "" && d.code != "" {
+		slug = d.code
+	}
+	if slug == "" {
+		slug = rfc7807.slug(d.title)
+	}
+	for n := 1; ; n++ {
+		candidate := slug
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d", slug, n)
+		}
+
+		p, fragment := rfc7807.docLocation(candidate)
+		if err := checkPattern(routePath(p)); err != nil {
+			return fmt.Errorf("%v for %q", err, d.title)
+		}
+		docURL := rfc7807.typeURL(p) + fragment
+		owner, taken := rfc7807.routes[docURL]
+		if !taken || owner == d.title {
+			rfc7807.routes[docURL] = d.title
+			d.path, d.fragment, d.url = p, fragment, docURL
+			if rfc7807.singleDocPage == "" {
+				for lang, locale := range d.locales {
+					locale.path = strings.TrimSuffix(p, ".html") + "." + rfc7807.escape(lang) + ".html"
+					locale.url = rfc7807.typeURL(locale.path)
+					locale.noCache = d.noCache
+					rfc7807.routes[locale.url] = d.title
+				}
+				rfc7807.serveDoc(d)
+			}
+			return nil
+		}
+
+		if !rfc7807.slugSuffixes {
+			return fmt.Errorf("rfc7807: doc page of %q collides with the one of %q at %q", d.title, owner, docURL)
+		}
+	}
+}
+
+// checkPattern rejects doc paths with characters chi reads as pattern syntax,
+// which would register a parameter or wildcard route instead of the page.
+func checkPattern(p string) error {
+	if strings.ContainsAny(p, "{}*") {
+		return fmt.Errorf("rfc7807: doc path %q contains a chi pattern character ({, } or *)", p)
+	}
+
+	return nil
+}
+
+// routePath returns the decoded form of the escaped doc path p, which is what
+// the router matches requests against.
+func routePath(p string) string {
+	if decoded, err := url.PathUnescape(p); err == nil {
+		return decoded
+	}
+
+	return p
+}
+
+// docLocation returns the escaped path and the fragment of the doc page for slug.
+func (rfc7807 *RFC7807) docLocation(slug string) (string, string) {
+	if rfc7807.singleDocPage != "" {
+		fragment := &url.URL{Fragment: anchor(slug)}
+		return rfc7807.singleDocPage, fragment.String()
+	}
+
+	return fmt.Sprintf("/%s.html", rfc7807.escape(slug)), ""
+}
+
+// escape escapes a doc page name with the WithPathEscaper escaper, or
+// url.PathEscape by default.
+func (rfc7807 *RFC7807) escape(name string) string {
+	if rfc7807.pathEscaper != nil {
+		return rfc7807.pathEscaper(name)
+	}
+
+	return url.PathEscape(name)
+}
+
+func (rfc7807 *RFC7807) slug(title string) string {
+	if rfc7807.slugger != nil {
+		return rfc7807.slugger(title)
+	}
+
+	return title
+}
+
+// newMux returns an empty doc mux answering unknown paths with a 404 problem.
+func (rfc7807 *RFC7807) newMux() *chi.Mux {
+	mux := chi.NewMux()
+	mux.NotFound(func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		rfc7807.ErrorRequest(aWriter, aRequest, "", http.StatusNotFound, "")
+	})
+
+	return mux
+}
+
+func (rfc7807 *RFC7807) serveDoc(doc *doc) string {
 	if rfc7807.mux == nil {
-		rfc7807.mux = chi.NewMux()
+		rfc7807.mux = rfc7807.newMux()
 	}
 
-	docURL := ""
-	if html != nil && len(html) > 0 {
-		p := fmt.Sprintf("/%s.html", url.PathEscape(title))
+	rfc7807.mux.Get(routePath(doc.path), func(aWriter http.ResponseWriter, aRequest *http.Request) {
+		vary(aWriter, "Accept")
+		if wantsDescriptor(aRequest) {
+			rfc7807.writeDescriptor(aWriter, aRequest, doc)
+			return
+		}
+
+		if doc.gzipped != nil {
+			vary(aWriter, "Accept-Encoding")
+			if acceptsGzip(aRequest) {
+				rfc7807.writeGzipDoc(aWriter, aRequest, doc.gzipped, doc.noCache)
+				return
+			}
+		}
+
+		html, err := rfc7807.docBytes(aWriter, doc, aRequest)
+		if err != nil {
+			rfc7807.ErrorRequest(aWriter, aRequest, "", http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		rfc7807.writeDoc(aWriter, aRequest, html, doc.noCache)
+	})
+
+	for _, locale := range doc.locales {
+		if locale.path != "" {
+			rfc7807.serveDoc(locale)
+		}
+	}
+
+	return rfc7807.typeURL(doc.path)
+}
+
+func (rfc7807 *RFC7807) docBytes(w http.ResponseWriter, doc *doc, r *http.Request) ([]byte, error) {
+	if len(doc.variants) > 0 {
+		for _, field := range rfc7807.docVariantVary {
+			vary(w, field)
+		}
+
+		variant := publicVariant
+		if rfc7807.docVariant != nil {
+			if v := rfc7807.docVariant(r); v != "" {
+				variant = v
+			}
+		}
+
+		if html, ok := doc.variants[variant]; ok {
+			return html, nil
+		}
+	}
+
+	return doc.bytes()
+}
+
+// base returns the parsed base URL, parsing URL again only if it was changed
+// after construction.
+func (rfc7807 *RFC7807) base() (*url.URL, error) {
+	if rfc7807.baseURL != nil && rfc7807.baseURL.String() == rfc7807.URL {
+		return rfc7807.baseURL, nil
+	}
+
+	return url.Parse(rfc7807.URL)
+}
+
+// typeURL resolves the escaped doc path p against the base URL. An empty base
+// yields the relative path itself.
+func (rfc7807 *RFC7807) typeURL(p string) string {
+	if rfc7807.URL == "" {
+		return p
+	}
+
+	base, err := rfc7807.base()
+	if err != nil {
+		return p
+	}
+
+	return joinURL(base, p)
+}
+
+// docURL returns the URL of the doc page of doc, or of its translation in the
+// language r prefers, resolved against the base URL of r when
+// WithDynamicBaseURL is set.
+func (rfc7807 *RFC7807) docURL(r *http.Request, doc *doc) string {
+	if r != nil && len(doc.locales) > 0 {
+		if locale := doc.locales[language(r, doc.locales)]; locale != nil && locale.path != "" {
+			doc = locale
+		}
+	}
+
+	if r == nil || rfc7807.dynamicBaseURL == nil || doc.path == "" {
+		return doc.url
+	}
+
+	baseURL := rfc7807.dynamicBaseURL(r)
+	if baseURL == "" {
+		return doc.url
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return doc.url
+	}
+
+	return joinURL(base, doc.path) + doc.fragment
+}
+
+func joinURL(base *url.URL, p string) string {
+	u := *base
+	u.RawPath = strings.TrimSuffix(base.EscapedPath(), "/") + p
+
+	var err error
+	if u.Path, err = url.PathUnescape(u.RawPath); err != nil {
+		return p
+	}
+
+	return u.String()
+}
+
+// Error writes the problem registered under title and returns the status
+// written, for callers that log the outcome, or 0 when a writer from Wrap had
+// already committed the response.
+func (rfc7807 *RFC7807) Error(w http.ResponseWriter, title string, status int, detail string, extensions ...*Extension) int {
+	return rfc7807.error(w, nil, title, status, detail, extensions)
+}
+
+// ErrorCode is like Error, but looks the problem up by code, as registered with
+// the Code doc option. Declaring codes as a user-defined type with a String
+// method, e.g. "type Code int", keeps call sites type-checked. A code that was
+// not registered is used as the title.
+func (rfc7807 *RFC7807) ErrorCode(w http.ResponseWriter, code fmt.Stringer, status int, detail string, extensions ...*Extension) int {
+	return rfc7807.error(w, nil, rfc7807.codeTitle(code.String()), status, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) codeTitle(code string) string {
+	rfc7807.registry.RLock()
+	defer rfc7807.registry.RUnlock()
+
+	if title, ok := rfc7807.codes[code]; ok {
+		return title
+	}
+
+	return code
+}
+
+// Errorf is like Error, with detail formatted from format and args. Extensions
+// cannot be passed, as the variadic args take their place; use Error for them.
+func (rfc7807 *RFC7807) Errorf(w http.ResponseWriter, title string, status int, format string, args ...interface{}) int {
+	return rfc7807.Error(w, title, status, fmt.Sprintf(format, args...))
+}
+
+// ErrorE is like Error, but with WithStrictTitles it writes nothing and returns
+// an error when title is not registered.
+func (rfc7807 *RFC7807) ErrorE(w http.ResponseWriter, title string, status int, detail string, extensions ...*Extension) error {
+	if err := rfc7807.checkTitle(title); err != nil {
+		return err
+	}
+
+	rfc7807.error(w, nil, title, status, detail, extensions)
+	return nil
+}
+
+// MustError is like ErrorE, but panics instead of returning the error.
+func (rfc7807 *RFC7807) MustError(w http.ResponseWriter, title string, status int, detail string, extensions ...*Extension) {
+	if err := rfc7807.ErrorE(w, title, status, detail, extensions...); err != nil {
+		panic(err)
+	}
+}
+
+// checkTitle reports an unregistered title under WithStrictTitles. The empty
+// title, which stands for the status text, is always allowed.
+func (rfc7807 *RFC7807) checkTitle(title string) error {
+	if !rfc7807.strictTitles || title == "" || rfc7807.lookup(title) != nil {
+		return nil
+	}
+
+	return fmt.Errorf("rfc7807: problem %q is not registered", title)
+}
+
+// ErrorDefault is like Error, but uses the status registered for title with the
+// DefaultStatus doc option, or 500 when there is none.
+func (rfc7807 *RFC7807) ErrorDefault(w http.ResponseWriter, title string, detail string, extensions ...*Extension) int {
+	return rfc7807.error(w, nil, title, rfc7807.defaultStatus(title), detail, extensions)
+}
+
+func (rfc7807 *RFC7807) defaultStatus(title string) int {
+	if doc := rfc7807.lookup(title); doc != nil && doc.status != 0 {
+		return doc.status
+	}
+
+	return http.StatusInternalServerError
+}
+
+func (rfc7807 *RFC7807) ErrorRequest(w http.ResponseWriter, r *http.Request, title string, status int, detail string, extensions ...*Extension) int {
+	return rfc7807.error(w, r, title, status, detail, extensions)
+}
 
-		rfc7807.mux.Get(p, func(aWriter http.ResponseWriter, aRequest *http.Request) {
-			aWriter.WriteHeader(http.StatusOK)
-			aWriter.Header().Set("Content-Type", "text/html; charset=utf-8")
-			aWriter.Write(html)
-		})
+func (rfc7807 *RFC7807) error(w http.ResponseWriter, r *http.Request, title string, status int, detail string, extensions []*Extension) int {
+	return rfc7807.write(w, r, title, status, detail, extensions, nil)
+}
 
-		url, _ := url.Parse(rfc7807.URL)
-		url.Path = path.Join(url.Path, p)
-		docURL = url.String()
+// write is error with the per-call options of ErrorOpts, nil for none.
+func (rfc7807 *RFC7807) write(w http.ResponseWriter, r *http.Request, title string, status int, detail string, extensions []*Extension, options *writeOptions) int {
+	status = rfc7807.clampStatus(status)
+	if guard, ok := w.(*guardWriter); ok && guard.committed {
+		rfc7807.logf("rfc7807: response already committed, dropped %q (%d)", title, status)
+		return 0
+	}
+	if r != nil && r.Method == http.MethodHead {
+		head := &headWriter{ResponseWriter: w}
+		defer head.finish()
+		w = head
 	}
 
-	if rfc7807.problemHandlers == nil {
-		rfc7807.problemHandlers = map[string]problemHandlerFunc{}
+	doc := rfc7807.lookup(title)
+	extensions = rfc7807.allowedExtensions(doc, extensions)
+
+	if err := rfc7807.checkTitle(title); err != nil {
+		rfc7807.logf("%v", err)
 	}
 
-	rfc7807.problemHandlers[title] = func(w http.ResponseWriter, status int, detail string, extensions ...*Extension) {
-		problem := map[string]interface{}{}
+	if doc != nil {
+		atomic.AddInt64(&doc.emitted, 1)
+	}
 
-		for _, extension := range extensions {
-			problem[extension.Key] = extension.Value
+	if r != nil && rfc7807.requestIDHeader != "" {
+		id := r.Header.Get(rfc7807.requestIDHeader)
+		if id == "" {
+			id = rfc7807.uuid()
 		}
+		w.Header().Set(rfc7807.requestIDHeader, id)
+		extensions = append(extensions[:len(extensions):len(extensions)], Ext(rfc7807.requestIDMember, id))
+	}
 
-		problem["type"] = docURL
-		problem["title"] = title
-		problem["status"] = status
-		problem["detail"] = detail
+	if doc != nil {
+		for key, values := range doc.header {
+			w.Header()[key] = append([]string(nil), values...)
+		}
+	}
 
+	if status == http.StatusNoContent || status == http.StatusNotModified || (doc != nil && doc.noBody) {
 		w.WriteHeader(status)
-		w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
-		encoder := json.NewEncoder(w)
-		encoder.SetIndent("", "  ")
-		encoder.Encode(problem)
+		return status
+	}
+
+	if r != nil {
+		vary(w, "Accept")
+		if doc != nil && len(doc.locales) > 0 {
+			vary(w, "Accept-Language")
+		}
+	}
+
+	if doc == nil && len(extensions) == 0 && options == nil && rfc7807.fastPath(status) && (r == nil || rfc7807.negotiate(r) == "") {
+		if title == "" {
+			title = rfc7807.statusTitle(status)
+		}
+		rfc7807.writeFastProblem(w, r, status, title, detail)
+		return status
+	}
+
+	problem := rfc7807.finalize(rfc7807.problem(r, doc, title, status, detail, extensions), r)
+
+	rfc7807.report(problem, r)
+
+	if r != nil && (options == nil || options.mediaType == "") && rfc7807.acceptsHTML(r) {
+		if html, err := rfc7807.errorPage(doc, problem.Title, status, detail); err == nil {
+			rfc7807.writeHTML(w, status, html)
+			return status
+		}
+	}
+
+	// A fragment-only type would make the response its own location.
+	if rfc7807.contentLocation && doc != nil && doc.url != "" && !strings.HasPrefix(doc.url, "#") {
+		w.Header().Set("Content-Location", rfc7807.docURL(r, doc))
+	}
+
+	if rfc7807.linkHeader {
+		setLinkHeader(w, extensions)
 	}
 
-	return rfc7807.problemHandlers[title]
+	rfc7807.writeProblem(w, r, problem, options)
+	return status
 }
 
-func (rfc7807 *RFC7807) Error(w http.ResponseWriter, title string, status int, detail string, extensions ...*Extension) {
-	if handler := rfc7807.problemHandlers[title]; handler != nil {
-		handler(w, status, detail, extensions...)
-		return
+// allowedExtensions drops, with a warning, the extensions beyond the
+// WithMaxExtensions limit and those that doc does not allow.
+func (rfc7807 *RFC7807) allowedExtensions(doc *doc, extensions []*Extension) []*Extension {
+	if rfc7807.maxExtensions > 0 && len(extensions) > rfc7807.maxExtensions {
+		rfc7807.logf("rfc7807: dropped %d extensions over the limit of %d", len(extensions)-rfc7807.maxExtensions, rfc7807.maxExtensions)
+		extensions = extensions[:rfc7807.maxExtensions]
 	}
 
-	problem := map[string]interface{}{}
+	if doc == nil || doc.allowedExtensions == nil {
+		return extensions
+	}
 
+	allowed := make([]*Extension, 0, len(extensions))
 	for _, extension := range extensions {
-		problem[extension.Key] = extension.Value
+		if !doc.allowedExtensions[extension.Key] {
+			rfc7807.logf("rfc7807: dropped extension %q not allowed for %q", extension.Key, doc.title)
+			continue
+		}
+		allowed = append(allowed, extension)
+	}
+
+	return allowed
+}
+
+func (rfc7807 *RFC7807) logf(format string, args ...interface{}) {
+	if rfc7807.logger != nil {
+		rfc7807.logger.Printf(format, args...)
+		return
+	}
+
+	log.Printf(format, args...)
+}
+
+// Build returns the problem Error would write, as a map, without writing it
+// anywhere. It is meant for embedding problems in other envelopes or transports.
+func (rfc7807 *RFC7807) Build(title string, status int, detail string, extensions ...*Extension) map[string]interface{} {
+	doc := rfc7807.lookup(title)
+	return rfc7807.problem(nil, doc, title, status, detail, rfc7807.allowedExtensions(doc, extensions)).Map()
+}
+
+// WriteProblem writes an already built problem, e.g. one from GRPCProblem. Like
+// Error, it returns the status written, or 0 when a writer from Wrap had already
+// committed the response.
+func (rfc7807 *RFC7807) WriteProblem(w http.ResponseWriter, r *http.Request, problem *Problem) int {
+	if status := rfc7807.clampStatus(problem.Status); status != problem.Status {
+		clamped := *problem
+		clamped.Status = status
+		problem = &clamped
+	}
+	if guard, ok := w.(*guardWriter); ok && guard.committed {
+		rfc7807.logf("rfc7807: response already committed, dropped %q (%d)", problem.Title, problem.Status)
+		return 0
+	}
+	problem = rfc7807.finalize(problem, r)
+
+	rfc7807.report(problem, r)
+	rfc7807.writeProblem(w, r, problem, nil)
+	return problem.Status
+}
+
+// clampStatus replaces a status outside 200-599 with 500: a problem is a final
+// response, which a 1xx status is not, and net/http cannot write the rest. It is
+// applied once, before anything else looks at the status, so the status member
+// and the response status always agree.
+func (rfc7807 *RFC7807) clampStatus(status int) int {
+	if status < 200 || status > 599 {
+		rfc7807.logf("rfc7807: invalid status %d replaced with 500", status)
+		return http.StatusInternalServerError
+	}
+
+	return status
+}
+
+func (rfc7807 *RFC7807) errorPage(doc *doc, title string, status int, detail string) ([]byte, error) {
+	if doc != nil && doc.path != "" {
+		return doc.render(status, detail)
 	}
 
-	if title == "" {
-		title = http.StatusText(status)
+	template, tError := template.New("default.tpl").Parse(DefaultTemplate)
+	if tError != nil {
+		return nil, tError
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	if err := template.Execute(buf, map[string]interface{}{"Title": title, "Status": status, "Detail": detail}); err != nil {
+		return nil, err
 	}
 
-	problem["title"] = title
-	problem["status"] = status
-	problem["detail"] = detail
+	return rfc7807.styled(buf.Bytes()), nil
+}
 
+func (rfc7807 *RFC7807) writeHTML(w http.ResponseWriter, status int, html []byte) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(status)
-	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "  ")
-	encoder.Encode(problem)
+	rfc7807.flush(w)
+	w.Write(html)
+	rfc7807.flush(w)
+}
+
+func (rfc7807 *RFC7807) problem(r *http.Request, doc *doc, title string, status int, detail string, extensions []*Extension) *Problem {
+	docURL := ""
+	if doc != nil {
+		docURL = rfc7807.docURL(r, doc)
+		if len(doc.extensions) > 0 {
+			extensions = append(append([]*Extension{}, doc.extensions...), extensions...)
+		}
+		if doc.profile != nil {
+			extensions = append([]*Extension{Ext("profile", rfc7807.docURL(r, doc.profile))}, extensions...)
+		}
+	} else {
+		if title == "" {
+			title = rfc7807.statusTitle(status)
+		}
+		if rfc7807.fallbackDoc != nil {
+			docURL = rfc7807.docURL(r, rfc7807.fallbackDoc)
+		}
+	}
+
+	if docURL == "" {
+		docURL = rfc7807.defaultType
+	}
+
+	if rfc7807.withoutType {
+		docURL = ""
+	}
+
+	if rfc7807.iriTypes {
+		docURL = toIRI(docURL)
+	}
+
+	if detail == "" && rfc7807.derivedDetailKey != "" {
+		detail = rfc7807.deriveDetail(extensions)
+	}
+
+	problem := &Problem{Type: docURL, Title: title, Status: status, Detail: detail}
+
+	members := make([]*Extension, 0, len(extensions))
+	for _, extension := range extensions {
+		if extension.Key == "instance" {
+			if instance, ok := extension.Value.(string); ok {
+				problem.Instance = instance
+			}
+			continue
+		}
+		if fn, isLazy := extension.Value.(lazyValue); isLazy {
+			extension = Ext(extension.Key, fn())
+		}
+		if b, isBytes := extension.Value.(debugBytes); isBytes {
+			if !rfc7807.debug {
+				continue
+			}
+			extension = Ext(extension.Key, base64.StdEncoding.EncodeToString(b))
+		}
+		if ok, isRetryable := extension.Value.(retryable); isRetryable {
+			key := rfc7807.retryableKey
+			if key == "" {
+				key = extension.Key
+			}
+			extension = Ext(key, bool(ok))
+		}
+		if encoder, ok := rfc7807.extensionEncoders[reflect.TypeOf(extension.Value)]; ok {
+			extension = Ext(extension.Key, encoder(extension.Value))
+		}
+		members = append(members, extension)
+	}
+
+	if problem.Instance == "" && rfc7807.generateInstance {
+		problem.Instance = rfc7807.instancePrefix + rfc7807.uuid()
+	}
+
+	if rfc7807.instanceClasses != nil && !rfc7807.instanceClasses[status/100] {
+		problem.Instance = ""
+	}
+
+	if problem.Instance != "" && rfc7807.instanceBase != "" {
+		problem.Instance = rfc7807.absoluteInstance(problem.Instance)
+	}
+
+	if rfc7807.nestedExtensions != "" && len(members) > 0 {
+		members = []*Extension{Ext(rfc7807.nestedExtensions, extensionObject(members))}
+	}
+
+	if rfc7807.namespace != "" && len(members) > 0 {
+		members = rfc7807.namespaced(members)
+	}
+
+	if rfc7807.keyCase != CaseAsIs {
+		members = extensionObject(members).cased(rfc7807.keyCase)
+	}
+
+	if rfc7807.sortedKeys {
+		members = extensionObject(members).sorted()
+	}
+	problem.Extensions = members
+
+	return problem
+}
+
+// deriveDetail formats the WithDerivedDetail summary from the length of the last
+// extension under its key, or returns "" when there is none or it is empty.
+func (rfc7807 *RFC7807) deriveDetail(extensions []*Extension) string {
+	for i := len(extensions) - 1; i >= 0; i-- {
+		if extensions[i].Key != rfc7807.derivedDetailKey {
+			continue
+		}
+
+		value := reflect.ValueOf(extensions[i].Value)
+		switch value.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			if value.Len() > 0 {
+				return fmt.Sprintf(rfc7807.derivedDetail, value.Len())
+			}
+		}
+		return ""
+	}
+
+	return ""
+}
+
+// namespaced nests call-site extensions under the WithExtensionNamespace member,
+// leaving the ones the library adds itself (request ID, stack, profile) top-level.
+func (rfc7807 *RFC7807) namespaced(members []*Extension) []*Extension {
+	top := make([]*Extension, 0, len(members))
+	nested := make([]*Extension, 0, len(members))
+	for _, extension := range members {
+		if (rfc7807.requestIDHeader != "" && extension.Key == rfc7807.requestIDMember) || extension.Key == "stack" || extension.Key == "profile" {
+			top = append(top, extension)
+			continue
+		}
+		nested = append(nested, extension)
+	}
+
+	if len(nested) == 0 {
+		return top
+	}
+
+	return append([]*Extension{Ext(rfc7807.namespace, extensionObject(nested))}, top...)
+}
+
+// absoluteInstance prefixes a relative instance with the WithInstanceBase URL.
+func (rfc7807 *RFC7807) absoluteInstance(instance string) string {
+	if u, err := url.Parse(instance); err == nil && u.IsAbs() {
+		return instance
+	}
+
+	return strings.TrimSuffix(rfc7807.instanceBase, "/") + "/" + strings.TrimPrefix(instance, "/")
+}
+
+// finalize returns a copy of problem with the WithFinalizer finalizers applied,
+// so that the problem of the caller is left as is and the one handed to the
+// reporter is not modified afterwards.
+func (rfc7807 *RFC7807) finalize(problem *Problem, r *http.Request) *Problem {
+	if len(rfc7807.finalizers) == 0 {
+		return problem
+	}
+
+	finalized := *problem
+	finalized.Extensions = make([]*Extension, len(problem.Extensions))
+	for i, extension := range problem.Extensions {
+		if extension != nil {
+			copied := *extension
+			extension = &copied
+		}
+		finalized.Extensions[i] = extension
+	}
+	for _, finalize := range rfc7807.finalizers {
+		finalize(&finalized, r)
+	}
+
+	return &finalized
+}
+
+// writeProblem writes problem with its own status member as the response status,
+// applying the ErrorOpts options if not nil.
+func (rfc7807 *RFC7807) writeProblem(w http.ResponseWriter, r *http.Request, problem *Problem, options *writeOptions) {
+	contentType, indent := "application/problem+json; charset=utf-8", rfc7807.indent(r)
+	if options != nil {
+		if options.mediaType != "" {
+			contentType = options.mediaType
+		}
+		if options.indent != nil {
+			indent = *options.indent
+		}
+	}
+	negotiated := options == nil || options.mediaType == ""
+
+	if marshaler := rfc7807.marshaler(r); negotiated && marshaler != nil {
+		body, err := marshaler.Marshal(problem.Map())
+		if err == nil {
+			w.Header().Set("Content-Type", marshaler.ContentType())
+			w.WriteHeader(problem.Status)
+			w.Write(body)
+			return
+		}
+		rfc7807.logf("rfc7807: failed to marshal problem as %s, falling back to JSON: %v", marshaler.ContentType(), err)
+	}
+
+	if negotiated && rfc7807.errorFormat == FormatJSONAPI {
+		body, err := jsonAPIDocument(problem)
+		if err == nil {
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			w.WriteHeader(problem.Status)
+			w.Write(body)
+			return
+		}
+		rfc7807.logf("rfc7807: failed to write problem as JSON:API, falling back to RFC 7807: %v", err)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	for _, trailer := range rfc7807.trailers {
+		w.Header().Add("Trailer", trailer.name)
+	}
+	w.WriteHeader(problem.Status)
+	rfc7807.flush(w)
+	var body interface{} = problem
+	if rfc7807.envelope != nil {
+		raw, err := json.Marshal(problem)
+		if err != nil {
+			rfc7807.logf("rfc7807: failed to encode problem: %v", err)
+			return
+		}
+		body = rfc7807.envelope(raw)
+	}
+
+	if rfc7807.adaptiveIndent > 0 {
+		rfc7807.writeAdaptive(w, body, indent)
+	} else {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", indent)
+		if err := encoder.Encode(body); err != nil {
+			rfc7807.logf("rfc7807: failed to encode problem: %v", err)
+		}
+	}
+	for _, trailer := range rfc7807.trailers {
+		w.Header().Set(trailer.name, trailer.value(problem))
+	}
+	rfc7807.flush(w)
+}
+
+// writeAdaptive writes body indented if its compact form is smaller than the
+// WithAdaptiveIndent threshold, and compact otherwise.
+func (rfc7807 *RFC7807) writeAdaptive(w http.ResponseWriter, body interface{}, indent string) {
+	compact, err := json.Marshal(body)
+	if err != nil {
+		rfc7807.logf("rfc7807: failed to encode problem: %v", err)
+		return
+	}
+
+	if indent != "" && len(compact) < rfc7807.adaptiveIndent {
+		buf := bytes.NewBuffer(make([]byte, 0, 2*len(compact)))
+		if err := json.Indent(buf, compact, "", indent); err == nil {
+			compact = buf.Bytes()
+		}
+	}
+
+	w.Write(append(compact, '\n'))
+}
+
+// flush pushes what was written so far to the client when WithIncrementalFlush
+// is set and w supports it.
+func (rfc7807 *RFC7807) flush(w http.ResponseWriter) {
+	if !rfc7807.incrementalFlush {
+		return
+	}
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rfc7807 *RFC7807) indent(r *http.Request) string {
+	if rfc7807.prettyQueryParam == "" {
+		return "  "
+	}
+
+	if r != nil {
+		if pretty, _ := strconv.ParseBool(r.URL.Query().Get(rfc7807.prettyQueryParam)); pretty {
+			return "  "
+		}
+	}
+
+	return ""
+}
+
+// Close flushes async hooks, such as WithAsyncReporter, waiting for them until
+// ctx is done. It is a no-op when there are none. Problems written after Close
+// are no longer reported.
+func (rfc7807 *RFC7807) Close(ctx context.Context) error {
+	var first error
+	for _, closer := range rfc7807.closers {
+		if err := closer(ctx); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// DocHandler returns a handler serving only the doc routes, to be mounted at the
+// path of the base URL outside any auth middleware, e.g.
+//
+//	router.Mount("/errors", problems.DocHandler())
+//	router.Group(func(r chi.Router) { r.Use(auth); ... })
+func (rfc7807 *RFC7807) DocHandler() http.Handler {
+	return http.HandlerFunc(rfc7807.ServeHTTP)
 }
 
 func (rfc7807 *RFC7807) ServeHTTP(aWriter http.ResponseWriter, aRequest *http.Request) {
-	rfc7807.mux.ServeHTTP(aWriter, aRequest)
+	if rfc7807.cors != nil && rfc7807.cors.handle(aWriter, aRequest) {
+		return
+	}
+
+	rfc7807.registry.RLock()
+	mux := rfc7807.mux
+	rfc7807.registry.RUnlock()
+
+	mux.ServeHTTP(aWriter, aRequest)
+}
+
+// Stats returns how many times each registered problem has been written, by
+// title, including those never written, e.g. to prune unused problems.
+func (rfc7807 *RFC7807) Stats() map[string]int64 {
+	rfc7807.registry.RLock()
+	defer rfc7807.registry.RUnlock()
+
+	stats := make(map[string]int64, len(rfc7807.docs))
+	for title, doc := range rfc7807.docs {
+		stats[title] = atomic.LoadInt64(&doc.emitted)
+	}
+
+	return stats
+}
+
+// statusTitle returns the title of undocumented problems with status: the
+// WithStatusTitles one, or the status text.
+func (rfc7807 *RFC7807) statusTitle(status int) string {
+	if title, ok := rfc7807.statusTitles[status]; ok {
+		return title
+	}
+
+	return http.StatusText(status)
+}
+
+// lookup returns the doc registered under title, or nil.
+func (rfc7807 *RFC7807) lookup(title string) *doc {
+	rfc7807.registry.RLock()
+	defer rfc7807.registry.RUnlock()
+
+	return rfc7807.docs[title]
 }
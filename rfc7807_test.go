@@ -0,0 +1,812 @@
+package rfc7807
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serve sends a request for target through h and returns the recorded response.
+func serve(h http.Handler, method string, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for key, values := range header {
+		r.Header[key] = values
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// decode returns the JSON object of a recorded problem body.
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	problem := map[string]interface{}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("invalid problem body %q: %v", w.Body.String(), err)
+	}
+
+	return problem
+}
+
+func TestTemplateDocFuncs(t *testing.T) {
+	problems := New("http://example.com/errors")
+	funcs := template.FuncMap{"upper": strings.ToUpper}
+	if _, err := problems.TemplateDocFuncs("NotFound", "missing", "<h1>{{upper .Title}}</h1><p>{{.Description}}</p>", funcs); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(problems, http.MethodGet, "/NotFound.html", nil)
+	if want := "<h1>NOTFOUND</h1><p>missing</p>"; w.Body.String() != want {
+		t.Errorf("page = %q, want %q", w.Body.String(), want)
+	}
+}
+
+func TestTemplateDocWithoutFuncs(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.TemplateDoc("NotFound", "missing", "<h1>{{.Title}}</h1>"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.TemplateDoc("Broken", "", "<h1>{{upper .Title}}</h1>"); err == nil {
+		t.Error("TemplateDoc accepted a template calling an undefined func")
+	}
+
+	w := serve(problems, http.MethodGet, "/NotFound.html", nil)
+	if want := "<h1>NotFound</h1>"; w.Body.String() != want {
+		t.Errorf("page = %q, want %q", w.Body.String(), want)
+	}
+}
+
+func TestRelativeType(t *testing.T) {
+	problems := New("")
+	if _, err := problems.Doc("Not Found", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	problems.Error(w, "Not Found", 404, "")
+
+	typeURL, _ := decode(t, w)["type"].(string)
+	if want := "/Not%20Found.html"; typeURL != want {
+		t.Fatalf("type = %q, want %q", typeURL, want)
+	}
+
+	u, err := url.Parse(typeURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.IsAbs() || u.Path != "/Not Found.html" || u.String() != typeURL {
+		t.Errorf("type %q parses as %#v", typeURL, u)
+	}
+}
+
+func TestNewURL(t *testing.T) {
+	u, err := url.Parse("http://example.com/errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		problems *RFC7807
+		want     string
+	}{
+		{"New", New("http://example.com/errors"), "http://example.com/errors/NotFound.html"},
+		{"NewURL", NewURL(u), "http://example.com/errors/NotFound.html"},
+		{"NewURL nil", NewURL(nil), "/NotFound.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.problems.Doc("NotFound", "missing"); err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			test.problems.Error(w, "NotFound", 404, "")
+			if got := decode(t, w)["type"]; got != test.want {
+				t.Errorf("type = %v, want %q", got, test.want)
+			}
+			if errs := test.problems.Verify(); len(errs) != 0 {
+				t.Errorf("Verify() = %v", errs)
+			}
+		})
+	}
+}
+
+func TestNewInvalidURL(t *testing.T) {
+	problems := New("http://example.com/%zz")
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	problems.Error(w, "NotFound", 404, "")
+	if got := decode(t, w)["type"]; got != "/NotFound.html" {
+		t.Errorf("type = %v, want the relative path", got)
+	}
+
+	errs := problems.Verify()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "invalid base URL") {
+		t.Errorf("Verify() = %v, want the invalid base URL", errs)
+	}
+}
+
+func TestSlugCollision(t *testing.T) {
+	problems := New("http://example.com/errors", WithSlugger(Slugify))
+	if _, err := problems.Doc("Not Found", "first"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := problems.Doc("Not-Found", "second")
+	if err == nil || !strings.Contains(err.Error(), "collides") {
+		t.Fatalf("Doc() error = %v, want a collision", err)
+	}
+	if errs := problems.Verify(); len(errs) != 1 || errs[0].Error() != err.Error() {
+		t.Errorf("Verify() = %v, want the collision", errs)
+	}
+
+	if page := serve(problems, http.MethodGet, "/not-found.html", nil).Body.String(); !strings.Contains(page, "first") {
+		t.Errorf("doc page of the first title replaced: %s", page)
+	}
+}
+
+func TestSlugSuffixes(t *testing.T) {
+	problems := New("http://example.com/errors", WithSlugger(Slugify), WithSlugSuffixes())
+	for _, title := range []string{"Not Found", "Not-Found", "not found"} {
+		if _, err := problems.Doc(title, title); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, title := range []string{"Not Found", "Not-Found", "not found"} {
+		want := []string{"/not-found.html", "/not-found-2.html", "/not-found-3.html"}[i]
+		w := httptest.NewRecorder()
+		problems.Error(w, title, 404, "")
+		if got := decode(t, w)["type"]; got != "http://example.com/errors"+want {
+			t.Errorf("type of %q = %v, want %q", title, got, want)
+		}
+		if page := serve(problems, http.MethodGet, want, nil).Body.String(); !strings.Contains(page, "<pre>"+title+"</pre>") {
+			t.Errorf("%s does not document %q: %s", want, title, page)
+		}
+	}
+	if errs := problems.Verify(); len(errs) != 0 {
+		t.Errorf("Verify() = %v", errs)
+	}
+}
+
+func TestTemplateDocErrors(t *testing.T) {
+	problems := New("http://example.com/errors")
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"parse", "<h1>{{.Title</h1>", `rfc7807: failed to parse template for "Payment Declined": template: Payment Declined:`},
+		{"execute", "<h1>{{.Title.Missing}}</h1>", `rfc7807: failed to execute template for "Payment Declined": template: Payment Declined:`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := problems.TemplateDoc("Payment Declined", "", test.template)
+			if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+				t.Fatalf("TemplateDoc() error = %v, want %q...", err, test.want)
+			}
+			if errors.Unwrap(err) == nil {
+				t.Error("template error not wrapped")
+			}
+		})
+	}
+}
+
+func TestDocHandler(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				problems.Error(w, "", http.StatusUnauthorized, "")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	app := http.NewServeMux()
+	app.Handle("/errors/", http.StripPrefix("/errors", problems.DocHandler()))
+	app.Handle("/", auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		problems.Error(w, "NotFound", http.StatusNotFound, "")
+	})))
+
+	if w := serve(app, http.MethodGet, "/errors/NotFound.html", nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<h1>NotFound</h1>") {
+		t.Errorf("GET doc page = %d %q, want the page without credentials", w.Code, w.Body.String())
+	}
+	if w := serve(app, http.MethodGet, "/users/1", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("GET API = %d, want 401", w.Code)
+	}
+}
+
+func TestErrorPageDetail(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("NotFound", "The resource does not exist."); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, title := range []string{"NotFound", "Undocumented"} {
+		r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+		r.Header.Set("Accept", "text/html")
+		w := httptest.NewRecorder()
+		problems.ErrorRequest(w, r, title, 404, "user <42> does not exist")
+
+		if page := w.Body.String(); !strings.Contains(page, `<p class="problem-detail">user &lt;42&gt; does not exist</p>`) {
+			t.Errorf("%s page = %s, want the escaped detail", title, page)
+		}
+	}
+}
+
+func TestWithStrictTitles(t *testing.T) {
+	var logs bytes.Buffer
+	problems := New("http://example.com/errors", WithStrictTitles(), WithLogger(log.New(&logs, "", 0)))
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("registered", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		if err := problems.ErrorE(w, "NotFound", 404, ""); err != nil {
+			t.Fatalf("ErrorE() = %v", err)
+		}
+		if w.Code != 404 {
+			t.Errorf("status = %d, want 404", w.Code)
+		}
+		problems.MustError(httptest.NewRecorder(), "NotFound", 404, "")
+	})
+
+	t.Run("status title", func(t *testing.T) {
+		if err := problems.ErrorE(httptest.NewRecorder(), "", 404, ""); err != nil {
+			t.Errorf("ErrorE() with the empty title = %v", err)
+		}
+	})
+
+	t.Run("unregistered", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		err := problems.ErrorE(w, "NotFuond", 404, "")
+		if err == nil || !strings.Contains(err.Error(), `"NotFuond"`) {
+			t.Errorf("ErrorE() = %v, want an unregistered title error", err)
+		}
+		if w.Body.Len() != 0 || w.Code != http.StatusOK {
+			t.Errorf("ErrorE() wrote %d %q", w.Code, w.Body.String())
+		}
+
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("MustError() did not panic")
+				}
+			}()
+			problems.MustError(httptest.NewRecorder(), "NotFuond", 404, "")
+		}()
+
+		logs.Reset()
+		w = httptest.NewRecorder()
+		problems.Error(w, "NotFuond", 404, "")
+		if w.Code != 404 || !strings.Contains(logs.String(), `"NotFuond"`) {
+			t.Errorf("Error() = %d, logged %q, want the problem and a warning", w.Code, logs.String())
+		}
+	})
+}
+
+func TestErrorf(t *testing.T) {
+	problems := New("http://example.com/errors")
+
+	tests := []struct {
+		name   string
+		format string
+		args   []interface{}
+		want   string
+	}{
+		{"verbs", "user %d not found in %q", []interface{}{42, "eu"}, `user 42 not found in "eu"`},
+		{"no args", "100%% plain", nil, "100% plain"},
+		{"missing arg", "user %d", nil, "user %!d(MISSING)"},
+		// An extension is just another arg: it is formatted, not emitted.
+		{"extension", "balance %v", []interface{}{Ext("balance", 30)}, fmt.Sprintf("balance %v", Ext("balance", 30))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if got := problems.Errorf(w, "NotFound", 404, test.format, test.args...); got != 404 {
+				t.Errorf("Errorf() = %d, want 404", got)
+			}
+
+			problem := decode(t, w)
+			if problem["detail"] != test.want {
+				t.Errorf("detail = %q, want %q", problem["detail"], test.want)
+			}
+			if _, ok := problem["balance"]; ok {
+				t.Error("extension passed as an arg was emitted")
+			}
+		})
+	}
+}
+
+func TestClampStatus(t *testing.T) {
+	var logs bytes.Buffer
+	problems := New("http://example.com/errors", WithLogger(log.New(&logs, "", 0)))
+
+	tests := []struct {
+		status int
+		want   int
+	}{
+		{-1, 500},
+		{42, 500},
+		{100, 500},
+		{103, 500},
+		{199, 500},
+		{200, 200},
+		{404, 404},
+		{599, 599},
+		{600, 500},
+		{1000, 500},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.status), func(t *testing.T) {
+			logs.Reset()
+			w := httptest.NewRecorder()
+			if got := problems.Error(w, "", test.status, ""); got != test.want {
+				t.Errorf("Error() = %d, want %d", got, test.want)
+			}
+			if w.Code != test.want || decode(t, w)["status"] != float64(w.Code) {
+				t.Errorf("response status %d, member %v, want both %d", w.Code, decode(t, w)["status"], test.want)
+			}
+			if clamped := test.status != test.want; clamped != strings.Contains(logs.String(), "invalid status") {
+				t.Errorf("logged %q for status %d", logs.String(), test.status)
+			}
+
+			w = httptest.NewRecorder()
+			problems.WriteProblem(w, nil, &Problem{Title: "Clamped", Status: test.status})
+			if w.Code != test.want || decode(t, w)["status"] != float64(w.Code) {
+				t.Errorf("WriteProblem: response status %d, member %v, want both %d", w.Code, decode(t, w)["status"], test.want)
+			}
+		})
+	}
+}
+
+func TestDocNotFound(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/Unknown.html", "/", "/NotFound.html/extra"} {
+		w := serve(problems, http.MethodGet, path, nil)
+		if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/problem+json; charset=utf-8" {
+			t.Errorf("GET %s = %d %q, want a 404 problem", path, w.Code, w.Header().Get("Content-Type"))
+			continue
+		}
+		if problem := decode(t, w); problem["status"] != float64(404) || problem["title"] != "Not Found" {
+			t.Errorf("GET %s = %v, want the standard 404 problem", path, problem)
+		}
+	}
+
+	if w := serve(New("http://example.com/errors"), http.MethodGet, "/Unknown.html", nil); w.Code != http.StatusNotFound || decode(t, w)["status"] != float64(404) {
+		t.Errorf("GET without docs = %d %q, want a 404 problem", w.Code, w.Body.String())
+	}
+}
+
+// errorCode is a typed problem code, as ErrorCode expects callers to declare.
+type errorCode int
+
+const (
+	codeOutOfCredit errorCode = iota
+	codeFrozen
+	codeUnregistered
+)
+
+func (code errorCode) String() string {
+	return [...]string{"out-of-credit", "frozen", "unregistered"}[code]
+}
+
+// stringCode is a typed problem code with a string underlying type.
+type stringCode string
+
+func (code stringCode) String() string {
+	return string(code)
+}
+
+// Only typed codes are accepted; a bare string does not compile.
+var _ fmt.Stringer = codeOutOfCredit
+
+func TestErrorCode(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("You do not have enough credit.", "", Code(codeOutOfCredit.String())); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.Doc("Account frozen", "", Code(codeFrozen.String())); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		code    fmt.Stringer
+		title   string
+		typeURL string
+	}{
+		{codeOutOfCredit, "You do not have enough credit.", "http://example.com/errors/out-of-credit.html"},
+		{codeFrozen, "Account frozen", "http://example.com/errors/frozen.html"},
+		{stringCode("frozen"), "Account frozen", "http://example.com/errors/frozen.html"},
+		{codeUnregistered, "unregistered", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.code.String(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if got := problems.ErrorCode(w, test.code, 403, "detail", Ext("balance", 30)); got != 403 {
+				t.Errorf("ErrorCode() = %d, want 403", got)
+			}
+
+			problem := decode(t, w)
+			if problem["title"] != test.title || problem["balance"] != float64(30) {
+				t.Errorf("problem = %v, want title %q with the extension", problem, test.title)
+			}
+			if got, _ := problem["type"].(string); got != test.typeURL {
+				t.Errorf("type = %q, want %q", got, test.typeURL)
+			}
+		})
+	}
+}
+
+func TestExtValues(t *testing.T) {
+	problems := New("http://example.com/errors", WithPrettyQueryParam("pretty"))
+
+	tests := []struct {
+		name   string
+		values url.Values
+		want   string
+	}{
+		{"sorted", url.Values{"zone": {""}, "limit": {"-1"}, "sort": {"name", "date"}, "Accept": {"x"}}, `"params":{"Accept":["x"],"limit":["-1"],"sort":["name","date"],"zone":[""]}`},
+		{"empty", url.Values{}, `"params":{}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var first string
+			for i := 0; i < 20; i++ {
+				w := httptest.NewRecorder()
+				problems.Error(w, "Invalid Query", 400, "", ExtValues("params", test.values))
+				if i == 0 {
+					first = w.Body.String()
+				} else if w.Body.String() != first {
+					t.Fatalf("run %d:\ngot  %s\nwant %s", i, w.Body.String(), first)
+				}
+			}
+
+			if !strings.Contains(first, test.want) {
+				t.Errorf("body %s lacks %s", first, test.want)
+			}
+		})
+	}
+}
+
+func TestExtBytes(t *testing.T) {
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+
+	debug := New("http://example.com/errors", WithDebug())
+	production := New("http://example.com/errors")
+
+	for _, b := range [][]byte{{}, {0}, {0xff, 0xfe}, []byte("fp!"), all} {
+		w := httptest.NewRecorder()
+		debug.Error(w, "Out of Credit", 403, "", ExtBytes("fingerprint", b))
+		encoded, ok := decode(t, w)["fingerprint"].(string)
+		if !ok {
+			t.Fatalf("fingerprint of %x missing in debug mode", b)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || !bytes.Equal(decoded, b) {
+			t.Errorf("fingerprint %q decodes to %x (%v), want %x", encoded, decoded, err, b)
+		}
+
+		w = httptest.NewRecorder()
+		production.Error(w, "Out of Credit", 403, "", ExtBytes("fingerprint", b), Ext("balance", 30))
+		if problem := decode(t, w); problem["fingerprint"] != nil || problem["balance"] != float64(30) {
+			t.Errorf("problem = %v, want the fingerprint dropped outside debug mode", problem)
+		}
+	}
+
+	// Problems built by hand never went through the debug check.
+	problem := &Problem{Title: "Out of Credit", Status: 403, Extensions: []*Extension{ExtBytes("fingerprint", []byte("fp!")), Ext("balance", 30)}}
+	writes := map[string]func(w *httptest.ResponseRecorder){
+		"WriteProblem": func(w *httptest.ResponseRecorder) { production.WriteProblem(w, nil, problem) },
+		"ErrorMany":    func(w *httptest.ResponseRecorder) { production.ErrorMany(w, 403, problem) },
+		"EncodeNDJSON": func(w *httptest.ResponseRecorder) { production.EncodeNDJSON(w, problem) },
+		"Map": func(w *httptest.ResponseRecorder) {
+			if _, ok := problem.Map()["fingerprint"]; ok {
+				w.WriteString("fingerprint")
+			}
+		},
+	}
+	for name, write := range writes {
+		w := httptest.NewRecorder()
+		write(w)
+		if body := w.Body.String(); strings.Contains(body, "fingerprint") || strings.Contains(body, "ZnAh") {
+			t.Errorf("%s wrote %s, want the fingerprint withheld", name, body)
+		}
+	}
+}
+
+func TestPatternCharacters(t *testing.T) {
+	dashes := func(name string) string { return strings.Replace(name, " ", "-", -1) }
+	problems := New("http://example.com/errors", WithPathEscaper(dashes))
+
+	for _, title := range []string{"Quota {user}", "Quota*"} {
+		_, err := problems.Doc(title, "")
+		if err == nil || !strings.Contains(err.Error(), "chi pattern character") || !strings.Contains(err.Error(), strconv.Quote(title)) {
+			t.Errorf("Doc(%q) error = %v, want a descriptive pattern error", title, err)
+		}
+	}
+	problems.Profile("billing {eu}", nil)
+
+	if errs := problems.Verify(); len(errs) != 3 {
+		t.Errorf("Verify() = %v, want the three pattern errors", errs)
+	}
+	for _, path := range []string{"/Quota-{user}.html", "/Quota-anyone.html", "/Quota.html", "/_profiles/billing-eu.html"} {
+		if w := serve(problems, http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404 without a pattern route", path, w.Code)
+		}
+	}
+
+	// Escaping does not help, as pages are routed by their decoded path.
+	escaped := New("http://example.com/errors")
+	if _, err := escaped.Doc("Quota {user}", ""); err == nil || !strings.Contains(err.Error(), "chi pattern character") {
+		t.Errorf("Doc() with the default escaper error = %v, want a pattern error", err)
+	}
+}
+
+func TestExternalDoc(t *testing.T) {
+	problems := New("http://example.com/errors", WithSlugger(Slugify))
+	typeURL := "https://www.iana.org/assignments/http-problem-types#Date-Out_of_Range?x=%2F"
+	handler := problems.ExternalDoc("Date Out of Range", typeURL)
+
+	w := httptest.NewRecorder()
+	problems.Error(w, "Date Out of Range", 400, "")
+	if got := decode(t, w)["type"]; got != typeURL {
+		t.Errorf("type = %v, want %q verbatim", got, typeURL)
+	}
+	w = httptest.NewRecorder()
+	handler(w, 400, "")
+	if got := decode(t, w)["type"]; got != typeURL {
+		t.Errorf("type from the handler = %v, want %q verbatim", got, typeURL)
+	}
+
+	for _, path := range []string{"/date-out-of-range.html", "/Date%20Out%20of%20Range.html", "/assignments/http-problem-types"} {
+		if w := serve(problems, http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, w.Code)
+		}
+	}
+	if len(problems.routes) != 0 {
+		t.Errorf("routes = %v, want none", problems.routes)
+	}
+	if errs := problems.Verify(); len(errs) != 0 {
+		t.Errorf("Verify() = %v", errs)
+	}
+}
+
+func TestStats(t *testing.T) {
+	problems := New("http://example.com/errors")
+	for _, title := range []string{"Hot", "Warm", "Unused"} {
+		if _, err := problems.Doc(title, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const writers, writes = 8, 100
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				problems.Error(httptest.NewRecorder(), "Hot", 400, "")
+				if j%2 == 0 {
+					problems.ErrorRequest(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "Warm", 400, "")
+				}
+				problems.Error(httptest.NewRecorder(), "Unregistered", 400, "")
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		last := int64(0)
+		for i := 0; i < 200; i++ {
+			stats := problems.Stats()
+			if stats["Hot"] < last {
+				t.Errorf("Hot went from %d down to %d", last, stats["Hot"])
+			}
+			last = stats["Hot"]
+		}
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := problems.Doc("Late", ""); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	wg.Wait()
+	<-done
+
+	stats := problems.Stats()
+	want := map[string]int64{"Hot": writers * writes, "Warm": writers * writes / 2, "Unused": 0}
+	for title, count := range want {
+		if stats[title] != count {
+			t.Errorf("Stats()[%q] = %d, want %d", title, stats[title], count)
+		}
+	}
+	if _, ok := stats["Unregistered"]; ok {
+		t.Error("Stats() counts an unregistered title")
+	}
+}
+
+func TestExtFunc(t *testing.T) {
+	problems := New("http://example.com/errors", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if _, err := problems.Doc("Restricted", "", AllowExtensions("allowed")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		write func(ext *Extension)
+		calls int
+	}{
+		{"Error", func(ext *Extension) { problems.Error(httptest.NewRecorder(), "", 503, "", ext) }, 1},
+		{"ErrorRequest", func(ext *Extension) {
+			problems.ErrorRequest(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "", 503, "", ext)
+		}, 1},
+		{"HEAD", func(ext *Extension) {
+			problems.ErrorRequest(httptest.NewRecorder(), httptest.NewRequest(http.MethodHead, "/", nil), "", 503, "", ext)
+		}, 1},
+		{"two emissions", func(ext *Extension) {
+			problems.Error(httptest.NewRecorder(), "", 503, "", ext)
+			problems.Error(httptest.NewRecorder(), "", 503, "", ext)
+		}, 2},
+		{"not allowed", func(ext *Extension) { problems.Error(httptest.NewRecorder(), "Restricted", 503, "", ext) }, 0},
+		{"dropped", func(ext *Extension) {
+			w := problems.Wrap(httptest.NewRecorder())
+			w.WriteHeader(http.StatusOK)
+			problems.Error(w, "", 503, "", ext)
+		}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			test.write(ExtFunc("load", func() interface{} {
+				calls++
+				return 0.75
+			}))
+			if calls != test.calls {
+				t.Errorf("fn called %d times, want %d", calls, test.calls)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	problems.Error(w, "", 503, "", ExtFunc("load", func() interface{} { return 0.75 }))
+	if got := decode(t, w)["load"]; got != 0.75 {
+		t.Errorf("load = %v, want the computed value", got)
+	}
+
+	problem := &Problem{Title: "Unavailable", Status: 503, Extensions: []*Extension{ExtFunc("load", func() interface{} { return 0.75 })}}
+	w = httptest.NewRecorder()
+	problems.WriteProblem(w, nil, problem)
+	if got := decode(t, w)["load"]; got != 0.75 {
+		t.Errorf("load = %v, want the value computed for a problem built by hand", got)
+	}
+	if got := problem.Map()["load"]; got != 0.75 {
+		t.Errorf("Map()[load] = %v, want the computed value", got)
+	}
+}
+
+func TestEncodeErrorLogged(t *testing.T) {
+	var logs bytes.Buffer
+	problems := New("http://example.com/errors", WithLogger(log.New(&logs, "", 0)))
+
+	problems.WriteProblem(httptest.NewRecorder(), nil, &Problem{Title: "Broken", Status: 500, Extensions: []*Extension{Ext("ch", make(chan int))}})
+	if !strings.Contains(logs.String(), "rfc7807: failed to encode problem") {
+		t.Errorf("logs = %q, want the encode error", logs.String())
+	}
+}
+
+func TestFragmentType(t *testing.T) {
+	dynamic := func(r *http.Request) string { return "https://" + r.Host + "/errors" }
+	problems := New("http://example.com/errors", WithContentLocation(), WithDynamicBaseURL(dynamic))
+	problems.ExternalDoc("Not Found", "#/problems/not-found")
+	if _, err := problems.Doc("Gone", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		write    func(w http.ResponseWriter)
+		typeURL  string
+		location string
+	}{
+		{"Error", func(w http.ResponseWriter) { problems.Error(w, "Not Found", 404, "") }, "#/problems/not-found", ""},
+		{"ErrorRequest", func(w http.ResponseWriter) {
+			problems.ErrorRequest(w, httptest.NewRequest(http.MethodGet, "http://api.example.com/users/1", nil), "Not Found", 404, "")
+		}, "#/problems/not-found", ""},
+		{"served doc", func(w http.ResponseWriter) {
+			problems.ErrorRequest(w, httptest.NewRequest(http.MethodGet, "http://api.example.com/users/1", nil), "Gone", 410, "")
+		}, "https://api.example.com/errors/Gone.html", "https://api.example.com/errors/Gone.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			test.write(w)
+			if got := decode(t, w)["type"]; got != test.typeURL {
+				t.Errorf("type = %v, want %q", got, test.typeURL)
+			}
+			if got := w.Header().Get("Content-Location"); got != test.location {
+				t.Errorf("Content-Location = %q, want %q", got, test.location)
+			}
+		})
+	}
+
+	if errs := problems.Verify(); len(errs) != 0 {
+		t.Errorf("Verify() = %v", errs)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	problems := New("http://example.com/errors", WithLogger(log.New(ioutil.Discard, "", 0)))
+	if _, err := problems.Doc("Out of Credit", "", AllowExtensions("balance")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		title      string
+		extensions []*Extension
+		want       map[string]interface{}
+	}{
+		{"registered", "Out of Credit", []*Extension{Ext("balance", 30), Ext("secret", "x")}, map[string]interface{}{
+			"type": "http://example.com/errors/Out%20of%20Credit.html", "title": "Out of Credit", "status": 403, "detail": "low", "balance": 30,
+		}},
+		{"unregistered", "Unknown", []*Extension{Ext("nested", extensionObject{Ext("key", "value")})}, map[string]interface{}{
+			"title": "Unknown", "status": 403, "detail": "low", "nested": map[string]interface{}{"key": "value"},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := problems.Build(test.title, 403, "low", test.extensions...); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Build() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
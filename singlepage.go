@@ -0,0 +1,118 @@
+package rfc7807
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// WithSingleDocPage serves every doc as a section of one combined page at p
+// (e.g. "/errors") instead of a page per problem. Type URLs point at the
+// section, as in "<base>/errors#payment-declined".
+func WithSingleDocPage(p string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.singleDocPage = "/" + strings.TrimPrefix(p, "/")
+		if err := checkPattern(rfc7807.singleDocPage); err != nil {
+			rfc7807.errs = append(rfc7807.errs, err)
+			return
+		}
+		rfc7807.mux.Get(rfc7807.singleDocPage, rfc7807.serveSingleDocPage)
+	}
+}
+
+func (rfc7807 *RFC7807) serveSingleDocPage(aWriter http.ResponseWriter, aRequest *http.Request) {
+	rfc7807.registry.RLock()
+	docs := make([]*doc, 0, len(rfc7807.docs))
+	for _, doc := range rfc7807.docs {
+		if doc.path == rfc7807.singleDocPage {
+			docs = append(docs, doc)
+		}
+	}
+	rfc7807.registry.RUnlock()
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].title < docs[j].title
+	})
+
+	buf := bytes.NewBuffer(make([]byte, 0, 4096))
+	buf.WriteString("<html>\n<head>\n  <meta charset=\"utf-8\">\n  <title>Errors</title>\n")
+	buf.WriteString(rfc7807.docHead)
+	buf.WriteString("</head>\n<body>\n")
+	for _, doc := range docs {
+		html, err := doc.bytes()
+		if err != nil {
+			rfc7807.ErrorRequest(aWriter, aRequest, "", http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		buf.WriteString(`<section id="` + template.HTMLEscapeString(sectionID(doc)) + "\">\n")
+		buf.Write(bodyContent(html))
+		buf.WriteString("\n</section>\n")
+	}
+	buf.WriteString("</body>\n</html>")
+
+	rfc7807.writeDoc(aWriter, aRequest, buf.Bytes(), false)
+}
+
+// sectionID returns the id of the section of doc on the combined page, which is
+// the fragment of its type URL, slug and collision suffix included.
+func sectionID(doc *doc) string {
+	if u, err := url.Parse(doc.fragment); err == nil {
+		return u.Fragment
+	}
+
+	return strings.TrimPrefix(doc.fragment, "#")
+}
+
+// anchor derives a fragment identifier from title: lower-cased letters and
+// digits, with runs of anything else collapsed into a single dash.
+func anchor(title string) string {
+	var buf strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && buf.Len() > 0 {
+				buf.WriteByte('-')
+			}
+			buf.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+
+	return buf.String()
+}
+
+// bodyContent returns what is inside the body element of html, or html itself
+// when it has no body element.
+func bodyContent(html []byte) []byte {
+	lower := bytes.ToLower(html)
+
+	start := bytes.Index(lower, []byte("<body"))
+	if start < 0 {
+		return html
+	}
+
+	open := bytes.IndexByte(html[start:], '>')
+	if open < 0 {
+		return html
+	}
+	start += open + 1
+
+	end := bytes.LastIndex(lower, []byte("</body>"))
+	if end < start {
+		end = len(html)
+	}
+
+	return bytes.TrimSpace(html[start:end])
+}
+
+// Slugify turns a title into a lower-cased, dash-separated slug, e.g. "Not Found"
+// into "not-found". It is meant for WithSlugger.
+func Slugify(title string) string {
+	return anchor(title)
+}
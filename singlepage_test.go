@@ -0,0 +1,72 @@
+package rfc7807
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWithSingleDocPage(t *testing.T) {
+	problems := New("http://example.com/errors", WithSingleDocPage("/all"), WithSlugSuffixes())
+	register := []struct {
+		title   string
+		options []DocOption
+	}{
+		{"Payment Declined", nil},
+		{"Payment-Declined", nil},
+		{"Card Expired", []DocOption{Code("E1001")}},
+		{"Limit Exceeded", []DocOption{Slug("over limit")}},
+		{"見つかりません", nil},
+	}
+	for _, r := range register {
+		if _, err := problems.Doc(r.title, "about "+r.title, r.options...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page := serve(problems, http.MethodGet, "/all", nil).Body.String()
+	for _, r := range register {
+		w := httptest.NewRecorder()
+		problems.Error(w, r.title, 400, "")
+		typeURL, _ := decode(t, w)["type"].(string)
+
+		u, err := url.Parse(typeURL)
+		if err != nil || !strings.HasPrefix(typeURL, "http://example.com/errors/all#") {
+			t.Errorf("type of %q = %q, want a section of the combined page", r.title, typeURL)
+			continue
+		}
+		id := u.Fragment
+		if !strings.Contains(page, `<section id="`+id+`">`+"\n<h1>"+r.title+"</h1>") {
+			t.Errorf("no section %q for %q (type %q) in %s", id, r.title, typeURL, page)
+		}
+	}
+
+	tests := map[string]string{
+		"Payment Declined": "http://example.com/errors/all#payment-declined",
+		"Payment-Declined": "http://example.com/errors/all#payment-declined-2",
+		"Card Expired":     "http://example.com/errors/all#e1001",
+		"Limit Exceeded":   "http://example.com/errors/all#over-limit",
+	}
+	for title, want := range tests {
+		if got := problems.lookup(title).url; got != want {
+			t.Errorf("type of %q = %q, want %q", title, got, want)
+		}
+	}
+}
+
+func TestAnchor(t *testing.T) {
+	tests := map[string]string{
+		"Not Found":           "not-found",
+		"  Out of -- Credit ": "out-of-credit",
+		"E1001":               "e1001",
+		"見つかりません":             "見つかりません",
+		"!!!":                 "",
+	}
+	for title, want := range tests {
+		if got := anchor(title); got != want {
+			t.Errorf("anchor(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package rfc7807
+
+// Catalog is a snapshot of the registered problems and their doc pages. See
+// Snapshot and Restore.
+type Catalog struct {
+	docs map[string]*doc
+}
+
+// Snapshot returns the problems registered so far.
+func (rfc7807 *RFC7807) Snapshot() Catalog {
+	rfc7807.registry.RLock()
+	defer rfc7807.registry.RUnlock()
+
+	docs := make(map[string]*doc, len(rfc7807.docs))
+	for title, doc := range rfc7807.docs {
+		docs[title] = doc
+	}
+
+	return Catalog{docs: docs}
+}
+
+// Restore atomically replaces the registered problems with catalog and rebuilds
+// the doc mux from scratch, since chi cannot remove routes. To hot-reload, load
+// the new catalog into a fresh RFC7807 with the same options and restore its
+// Snapshot here; requests in flight keep the registry they started with.
+func (rfc7807 *RFC7807) Restore(catalog Catalog) {
+	mux := rfc7807.newMux()
+	routes := map[string]string{}
+	docs := make(map[string]*doc, len(catalog.docs))
+	codes := map[string]string{}
+
+	rfc7807.registry.Lock()
+	defer rfc7807.registry.Unlock()
+
+	rfc7807.mux = mux
+	if rfc7807.fallbackDoc != nil {
+		rfc7807.serveDoc(rfc7807.fallbackDoc)
+	}
+	for _, profile := range rfc7807.profiles {
+		rfc7807.serveDoc(profile)
+	}
+	if rfc7807.singleDocPage != "" {
+		mux.Get(rfc7807.singleDocPage, rfc7807.serveSingleDocPage)
+	}
+
+	for title, doc := range catalog.docs {
+		docs[title] = doc
+		if doc.code != "" {
+			codes[doc.code] = title
+		}
+		if doc.path == "" {
+			continue
+		}
+
+		routes[doc.url] = title
+		if rfc7807.singleDocPage == "" {
+			rfc7807.serveDoc(doc)
+		}
+	}
+
+	rfc7807.docs = docs
+	rfc7807.routes = routes
+	rfc7807.codes = codes
+	rfc7807.errs = nil
+}
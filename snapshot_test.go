@@ -0,0 +1,77 @@
+package rfc7807
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRestore(t *testing.T) {
+	catalog := func(title string) Catalog {
+		problems := New("http://example.com/errors")
+		if _, err := problems.Doc(title, title+" docs", Code(title+"-code")); err != nil {
+			t.Fatal(err)
+		}
+		return problems.Snapshot()
+	}
+	alpha, beta := catalog("Alpha"), catalog("Beta")
+
+	problems := New("http://example.com/errors")
+	problems.Restore(alpha)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				for _, path := range []string{"/Alpha-code.html", "/Beta-code.html"} {
+					if w := serve(problems, http.MethodGet, path, nil); w.Code != http.StatusOK && w.Code != http.StatusNotFound {
+						t.Errorf("GET %s = %d", path, w.Code)
+					}
+				}
+				w := httptest.NewRecorder()
+				problems.Error(w, "Alpha", 400, "")
+				if w.Code != 400 {
+					t.Errorf("Error() = %d, want 400", w.Code)
+				}
+				problems.Snapshot()
+			}
+		}()
+	}
+
+	for i := 0; i < 200; i++ {
+		if i%2 == 0 {
+			problems.Restore(beta)
+		} else {
+			problems.Restore(alpha)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	problems.Restore(beta)
+	if w := serve(problems, http.MethodGet, "/Beta-code.html", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /Beta-code.html = %d, want 200", w.Code)
+	}
+	if w := serve(problems, http.MethodGet, "/Alpha-code.html", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET /Alpha-code.html = %d, want 404 after the restore", w.Code)
+	}
+	if got := problems.codeTitle("Alpha-code"); got != "Alpha-code" {
+		t.Errorf("code of a dropped problem resolves to %q", got)
+	}
+	if got := problems.codeTitle("Beta-code"); got != "Beta" {
+		t.Errorf("code resolves to %q, want Beta", got)
+	}
+	if errs := problems.Verify(); len(errs) != 0 {
+		t.Errorf("Verify() = %v", errs)
+	}
+}
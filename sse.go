@@ -0,0 +1,28 @@
+package rfc7807
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// WriteSSE writes a problem as a Server-Sent Events "error" event, with the
+// compact problem JSON as data, and flushes it. It is meant for streams whose
+// header is already sent, so the status only goes into the problem.
+func (rfc7807 *RFC7807) WriteSSE(w http.ResponseWriter, title string, status int, detail string, extensions ...*Extension) error {
+	doc := rfc7807.lookup(title)
+	problem := rfc7807.problem(nil, doc, title, status, detail, rfc7807.allowedExtensions(doc, extensions))
+
+	data, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(append(append([]byte("event: error\ndata: "), data...), '\n', '\n')); err != nil {
+		return err
+	}
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package rfc7807
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// failingWriter fails every write, like a stream whose client went away.
+type failingWriter struct {
+	discardWriter
+}
+
+func (w failingWriter) Write(b []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestWriteSSE(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("JobFailed", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("event: progress\ndata: 50\n\n"))
+
+	if err := problems.WriteSSE(w, "JobFailed", 500, "disk full\nat step 3", Ext("step", 3)); err != nil {
+		t.Fatalf("WriteSSE() = %v", err)
+	}
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" || !w.Flushed {
+		t.Errorf("response = %d %q flushed %v, want the stream untouched and flushed", w.Code, w.Header().Get("Content-Type"), w.Flushed)
+	}
+
+	events := strings.Split(w.Body.String(), "\n\n")
+	if len(events) != 3 || events[0] != "event: progress\ndata: 50" || events[2] != "" {
+		t.Fatalf("stream = %q, want the progress and error events", w.Body.String())
+	}
+	lines := strings.Split(events[1], "\n")
+	if len(lines) != 2 || lines[0] != "event: error" || !strings.HasPrefix(lines[1], "data: ") {
+		t.Fatalf("error event = %q, want one event line and one data line", events[1])
+	}
+
+	problem := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem["type"] != "http://example.com/errors/JobFailed.html" || problem["status"] != float64(500) || problem["detail"] != "disk full\nat step 3" || problem["step"] != float64(3) {
+		t.Errorf("data = %v", problem)
+	}
+
+	if err := problems.WriteSSE(failingWriter{discardWriter{header: http.Header{}}}, "JobFailed", 500, ""); err == nil {
+		t.Error("WriteSSE() on a broken stream returned no error")
+	}
+	if err := problems.WriteSSE(httptest.NewRecorder(), "JobFailed", 500, "", Ext("bad", func() {})); err == nil {
+		t.Error("WriteSSE() with an unencodable extension returned no error")
+	}
+}
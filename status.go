@@ -0,0 +1,113 @@
+package rfc7807
+
+import (
+	"errors"
+	"net/http"
+)
+
+// The helpers below write a problem with their status and the status text, or
+// the WithStatusTitles title, as title, so a doc registered under that title
+// (e.g. "Not Found") is used. Like Error, they return the status written.
+
+func (rfc7807 *RFC7807) BadRequest(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusBadRequest, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) Unauthorized(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusUnauthorized, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) PaymentRequired(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusPaymentRequired, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) Forbidden(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusForbidden, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) NotFound(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusNotFound, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) MethodNotAllowed(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusMethodNotAllowed, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) NotAcceptable(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusNotAcceptable, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) RequestTimeout(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusRequestTimeout, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) Conflict(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusConflict, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) Gone(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusGone, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) PreconditionFailed(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusPreconditionFailed, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) RequestEntityTooLarge(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusRequestEntityTooLarge, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) UnsupportedMediaType(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusUnsupportedMediaType, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) InternalServerError(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusInternalServerError, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) NotImplemented(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusNotImplemented, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) BadGateway(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusBadGateway, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) ServiceUnavailable(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusServiceUnavailable, detail, extensions)
+}
+
+func (rfc7807 *RFC7807) GatewayTimeout(w http.ResponseWriter, detail string, extensions ...*Extension) int {
+	return rfc7807.status(w, http.StatusGatewayTimeout, detail, extensions)
+}
+
+// ErrorWithCause writes a problem titled with the status text and err as detail.
+// In debug mode, the messages of the errors err wraps are attached as a "causes"
+// extension. A nil err gives an empty detail.
+func (rfc7807 *RFC7807) ErrorWithCause(w http.ResponseWriter, status int, err error, extensions ...*Extension) int {
+	if err == nil {
+		return rfc7807.status(w, status, "", extensions)
+	}
+
+	if rfc7807.debug {
+		causes := []string{}
+		for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+			causes = append(causes, cause.Error())
+		}
+		extensions = append(extensions[:len(extensions):len(extensions)], Ext("causes", causes))
+	}
+
+	return rfc7807.status(w, status, err.Error(), extensions)
+}
+
+// status writes a problem titled with the status text, using the doc registered
+// under it if any. Unregistered, it goes as the empty title so WithStrictTitles
+// lets it through.
+func (rfc7807 *RFC7807) status(w http.ResponseWriter, status int, detail string, extensions []*Extension) int {
+	title := rfc7807.statusTitle(status)
+	if rfc7807.lookup(title) == nil {
+		title = ""
+	}
+
+	return rfc7807.error(w, nil, title, status, detail, extensions)
+}
@@ -0,0 +1,128 @@
+package rfc7807
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatusHelpers(t *testing.T) {
+	problems := New("http://example.com/errors")
+	helpers := map[int]func(http.ResponseWriter, string, ...*Extension) int{
+		http.StatusBadRequest:            problems.BadRequest,
+		http.StatusUnauthorized:          problems.Unauthorized,
+		http.StatusPaymentRequired:       problems.PaymentRequired,
+		http.StatusForbidden:             problems.Forbidden,
+		http.StatusNotFound:              problems.NotFound,
+		http.StatusMethodNotAllowed:      problems.MethodNotAllowed,
+		http.StatusNotAcceptable:         problems.NotAcceptable,
+		http.StatusRequestTimeout:        problems.RequestTimeout,
+		http.StatusConflict:              problems.Conflict,
+		http.StatusGone:                  problems.Gone,
+		http.StatusPreconditionFailed:    problems.PreconditionFailed,
+		http.StatusRequestEntityTooLarge: problems.RequestEntityTooLarge,
+		http.StatusUnsupportedMediaType:  problems.UnsupportedMediaType,
+		http.StatusInternalServerError:   problems.InternalServerError,
+		http.StatusNotImplemented:        problems.NotImplemented,
+		http.StatusBadGateway:            problems.BadGateway,
+		http.StatusServiceUnavailable:    problems.ServiceUnavailable,
+		http.StatusGatewayTimeout:        problems.GatewayTimeout,
+	}
+
+	for status, helper := range helpers {
+		t.Run(fmt.Sprint(status), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if got := helper(w, "detail", Ext("key", "value")); got != status {
+				t.Errorf("returned %d, want %d", got, status)
+			}
+
+			want := map[string]interface{}{"title": http.StatusText(status), "status": float64(status), "detail": "detail", "key": "value"}
+			if got := decode(t, w); w.Code != status || !reflect.DeepEqual(got, want) {
+				t.Errorf("problem = %d %v, want %d %v", w.Code, got, status, want)
+			}
+		})
+	}
+}
+
+func TestStatusHelperDoc(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("Not Found", "missing", Slug("not-found")); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	problems.NotFound(w, "")
+	if got := decode(t, w)["type"]; got != "http://example.com/errors/not-found.html" {
+		t.Errorf("type = %v, want the doc registered under the status text", got)
+	}
+}
+
+func TestWriterStatuses(t *testing.T) {
+	problems := New("http://example.com/errors", WithLogger(log.New(ioutil.Discard, "", 0)))
+	writers := []struct {
+		name   string
+		write  func(w http.ResponseWriter) int
+		status int
+	}{
+		{"TooManyRequests", func(w http.ResponseWriter) int { return problems.TooManyRequests(w, time.Second, "") }, http.StatusTooManyRequests},
+		{"Unprocessable", func(w http.ResponseWriter) int { return problems.Unprocessable(w) }, http.StatusUnprocessableEntity},
+		{"ErrorStruct", func(w http.ResponseWriter) int { return problems.ErrorStruct(w, "Conflict", 409, "", nil) }, http.StatusConflict},
+		{"WriteProblem", func(w http.ResponseWriter) int {
+			return problems.WriteProblem(w, nil, &Problem{Title: "Gone", Status: 410})
+		}, http.StatusGone},
+		{"WriteProblem clamped", func(w http.ResponseWriter) int {
+			return problems.WriteProblem(w, nil, &Problem{Title: "Early", Status: 103})
+		}, http.StatusInternalServerError},
+	}
+
+	for _, writer := range writers {
+		t.Run(writer.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if got := writer.write(w); got != writer.status || w.Code != writer.status {
+				t.Errorf("returned %d and wrote %d, want %d", got, w.Code, writer.status)
+			}
+
+			committed := problems.Wrap(httptest.NewRecorder())
+			committed.WriteHeader(http.StatusOK)
+			if got := writer.write(committed); got != 0 {
+				t.Errorf("returned %d on a committed response, want 0", got)
+			}
+		})
+	}
+}
+
+func TestErrorWithCause(t *testing.T) {
+	err := fmt.Errorf("loading order: %w", fmt.Errorf("query failed: %w", errors.New("connection reset")))
+
+	tests := []struct {
+		name    string
+		options []Option
+		err     error
+		want    map[string]interface{}
+	}{
+		{"production", nil, err, map[string]interface{}{"title": "Bad Gateway", "status": float64(502), "detail": err.Error()}},
+		{"debug", []Option{WithDebug()}, err, map[string]interface{}{
+			"title": "Bad Gateway", "status": float64(502), "detail": err.Error(),
+			"causes": []interface{}{"query failed: connection reset", "connection reset"},
+		}},
+		{"nil", []Option{WithDebug()}, nil, map[string]interface{}{"title": "Bad Gateway", "status": float64(502), "detail": ""}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if status := New("http://example.com/errors", test.options...).ErrorWithCause(w, http.StatusBadGateway, test.err); status != http.StatusBadGateway {
+				t.Errorf("returned %d, want 502", status)
+			}
+			if got := decode(t, w); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("problem = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package rfc7807
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ErrorStruct is like Error, but takes the extensions from the exported fields of
+// the struct v. Fields are named and skipped the way encoding/json does: json
+// tag names, "-" and omitempty are honored, and embedded structs are flattened.
+func (rfc7807 *RFC7807) ErrorStruct(w http.ResponseWriter, title string, status int, detail string, v interface{}) int {
+	return rfc7807.error(w, nil, title, status, detail, structExtensions(v))
+}
+
+func structExtensions(v interface{}) []*Extension {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return appendStructExtensions(nil, rv)
+}
+
+func appendStructExtensions(extensions []*Extension, rv reflect.Value) []*Extension {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, options := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, options = tag[:i], tag[i+1:]
+		}
+
+		value := rv.Field(i)
+		if field.Anonymous && name == "" {
+			embedded := value
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				extensions = appendStructExtensions(extensions, embedded)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if hasTagOption(options, "omitempty") && isEmptyValue(value) {
+			continue
+		}
+
+		extensions = append(extensions, Ext(name, value.Interface()))
+	}
+
+	return extensions
+}
+
+func hasTagOption(options string, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isEmptyValue reports whether v is empty in the sense of encoding/json's omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
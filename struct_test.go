@@ -0,0 +1,66 @@
+package rfc7807
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type Account struct {
+	ID string `json:"id"`
+}
+
+type creditProblem struct {
+	Account
+	Balance  int      `json:"balance"`
+	Accounts []string `json:"accounts,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+	Secret   string   `json:"-"`
+	Dash     string   `json:"-,"`
+	Plain    bool
+	Owner    struct {
+		Name string `json:"name"`
+	} `json:"owner"`
+	internal string
+}
+
+func TestStructExtensions(t *testing.T) {
+	v := creditProblem{Account: Account{ID: "a1"}, Balance: 30, Limit: 0, Secret: "s", Dash: "d", Plain: true, internal: "i"}
+	v.Owner.Name = "alice"
+
+	var keys []string
+	for _, extension := range structExtensions(&v) {
+		keys = append(keys, extension.Key)
+	}
+	if want := []string{"id", "balance", "-", "Plain", "owner"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %q, want %q", keys, want)
+	}
+
+	if extensions := structExtensions(nil); extensions != nil {
+		t.Errorf("structExtensions(nil) = %v", extensions)
+	}
+	if extensions := structExtensions((*creditProblem)(nil)); extensions != nil {
+		t.Errorf("structExtensions of a nil pointer = %v", extensions)
+	}
+	if extensions := structExtensions(42); extensions != nil {
+		t.Errorf("structExtensions of an int = %v", extensions)
+	}
+}
+
+func TestErrorStruct(t *testing.T) {
+	problems := New("http://example.com/errors")
+	v := creditProblem{Account: Account{ID: "a1"}, Balance: 30, Accounts: []string{"/account/1"}}
+	v.Owner.Name = "alice"
+
+	w := httptest.NewRecorder()
+	problems.ErrorStruct(w, "Out of Credit", 403, "", v)
+
+	want := map[string]interface{}{
+		"title": "Out of Credit", "status": 403.0, "detail": "",
+		"id": "a1", "balance": 30.0, "accounts": []interface{}{"/account/1"}, "-": "", "Plain": false,
+		"owner": map[string]interface{}{"name": "alice"},
+	}
+	if got := decode(t, w); !reflect.DeepEqual(got, want) {
+		t.Errorf("problem = %v, want %v", got, want)
+	}
+}
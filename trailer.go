@@ -0,0 +1,15 @@
+package rfc7807
+
+type trailer struct {
+	name  string
+	value func(*Problem) string
+}
+
+// WithTrailer declares the trailer field name on JSON problem responses and sets
+// it to value(problem) after the body, e.g. for streaming gateways reading a
+// summary. Where trailers are unsupported, such as HTTP/1.0, net/http drops it.
+func WithTrailer(name string, value func(problem *Problem) string) Option {
+	return func(rfc7807 *RFC7807) {
+		rfc7807.trailers = append(rfc7807.trailers, trailer{name: name, value: value})
+	}
+}
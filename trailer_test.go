@@ -0,0 +1,28 @@
+package rfc7807
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestWithTrailer(t *testing.T) {
+	summary := func(problem *Problem) string {
+		return strconv.Itoa(problem.Status) + " " + problem.Title
+	}
+	problems := New("http://example.com/errors", WithTrailer("X-Problem", summary), WithTrailer("X-Empty", func(*Problem) string { return "" }))
+
+	w := httptest.NewRecorder()
+	problems.Error(w, "Out of Credit", 403, "low")
+	response := w.Result()
+
+	if got := response.Header["Trailer"]; len(got) != 2 || got[0] != "X-Problem" || got[1] != "X-Empty" {
+		t.Errorf("Trailer = %q, want both trailers declared", got)
+	}
+	if got := response.Trailer.Get("X-Problem"); got != "403 Out of Credit" {
+		t.Errorf("X-Problem trailer = %q, want the summary", got)
+	}
+	if problem := decode(t, w); problem["title"] != "Out of Credit" || problem["X-Problem"] != nil {
+		t.Errorf("problem = %v, want the trailer kept out of the body", problem)
+	}
+}
@@ -0,0 +1,28 @@
+package rfc7807
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// newUUID returns a random (version 4) UUID in its canonical text form.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// uuid returns a UUID from the WithUUIDGenerator generator, or a random one.
+func (rfc7807 *RFC7807) uuid() string {
+	if rfc7807.uuidGenerator != nil {
+		return rfc7807.uuidGenerator()
+	}
+
+	return newUUID()
+}
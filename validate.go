@@ -0,0 +1,108 @@
+package rfc7807
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// voidElements have no end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// optionalEndElements may be left unclosed in valid HTML.
+var optionalEndElements = map[string]bool{
+	"html": true, "head": true, "body": true, "p": true, "li": true, "dt": true, "dd": true,
+	"option": true, "optgroup": true, "tr": true, "td": true, "th": true, "thead": true,
+	"tbody": true, "tfoot": true, "colgroup": true, "caption": true, "rt": true, "rp": true,
+}
+
+// validateHTML tokenizes page and reports the first element left unclosed or
+// closed without being open. Browsers would render such pages anyway, which is
+// why broken doc templates otherwise go unnoticed.
+func validateHTML(page []byte) error {
+	open := []string{}
+	tokenizer := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return err
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				if !optionalEndElements[open[i]] {
+					return fmt.Errorf("unclosed <%s>", open[i])
+				}
+			}
+			return nil
+
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if tag := strings.ToLower(string(name)); !voidElements[tag] {
+				open = append(open, tag)
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := strings.ToLower(string(name))
+			if voidElements[tag] {
+				continue
+			}
+
+			i := len(open) - 1
+			for i >= 0 && open[i] != tag {
+				i--
+			}
+			if i < 0 {
+				return fmt.Errorf("unexpected </%s>", tag)
+			}
+			for _, unclosed := range open[i+1:] {
+				if !optionalEndElements[unclosed] {
+					return fmt.Errorf("unclosed <%s> before </%s>", unclosed, tag)
+				}
+			}
+			open = open[:i]
+		}
+	}
+}
+
+// validateDoc checks the HTML of d and of its variants and translations. Lazy
+// docs are checked when baked instead.
+func validateDoc(d *doc) error {
+	if d.bake != nil {
+		bake := d.bake
+		d.bake = func() ([]byte, error) {
+			page, err := bake()
+			if err == nil {
+				if vErr := validateHTML(page); vErr != nil {
+					err = fmt.Errorf("rfc7807: invalid doc HTML for %q: %v", d.title, vErr)
+				}
+			}
+			return page, err
+		}
+	}
+
+	pages := [][]byte{d.html}
+	for _, variant := range d.variants {
+		pages = append(pages, variant)
+	}
+	for _, locale := range d.locales {
+		pages = append(pages, locale.html)
+	}
+
+	for _, page := range pages {
+		if len(page) == 0 {
+			continue
+		}
+		if err := validateHTML(page); err != nil {
+			return fmt.Errorf("rfc7807: invalid doc HTML for %q: %v", d.title, err)
+		}
+	}
+
+	return nil
+}
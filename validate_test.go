@@ -0,0 +1,69 @@
+package rfc7807
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateHTML(t *testing.T) {
+	tests := []struct {
+		page string
+		err  string
+	}{
+		{"<html><body><h1>Not Found</h1></body></html>", ""},
+		{"<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>x</title></head><body><p>open<br><img src=x></body></html>", ""},
+		{"<ul><li>one<li>two</ul>", ""},
+		{"<h1>Not Found</h1><p>no <em>closing tags", "unclosed <em>"},
+		{"<div><span>text</div>", "unclosed <span> before </div>"},
+		{"<h1>Not Found</h2>", "unexpected </h2>"},
+		{"<DIV>upper</div>", ""},
+	}
+
+	for _, test := range tests {
+		err := validateHTML([]byte(test.page))
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("validateHTML(%q) = %v, want %q", test.page, err, test.err)
+		}
+	}
+}
+
+func TestWithValidateDocs(t *testing.T) {
+	const broken = "<html><body><div><h1>{{.Title}}</h1></body></html>"
+
+	problems := New("http://example.com/errors", WithValidateDocs())
+	problems.HtmlDoc("BrokenPage", []byte("<div><p>open</div"))
+	if _, err := problems.TemplateDoc("BrokenTemplate", "", broken); err == nil || !strings.Contains(err.Error(), `invalid doc HTML for "BrokenTemplate": unclosed <div>`) {
+		t.Errorf("TemplateDoc() error = %v, want the unclosed <div>", err)
+	}
+	problems.HtmlDocVariants("BrokenVariant", map[string][]byte{"public": []byte("<p>fine</p>"), "internal": []byte("<b>bold")})
+	if _, err := problems.Doc("Valid", "fine"); err != nil {
+		t.Errorf("Doc() error = %v for a valid page", err)
+	}
+
+	errs := problems.Verify()
+	if len(errs) != 3 {
+		t.Errorf("Verify() = %v, want the three broken docs", errs)
+	}
+	for _, path := range []string{"/BrokenPage.html", "/BrokenTemplate.html", "/BrokenVariant.html"} {
+		if w := serve(problems, http.MethodGet, path, nil); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want the broken page not served", path, w.Code)
+		}
+	}
+	if w := serve(problems, http.MethodGet, "/Valid.html", nil); w.Code != http.StatusOK {
+		t.Errorf("GET /Valid.html = %d, want 200", w.Code)
+	}
+
+	lazy := New("http://example.com/errors", WithValidateDocs(), WithLazyDocs())
+	if _, err := lazy.TemplateDoc("BrokenTemplate", "", broken); err != nil {
+		t.Fatalf("lazy TemplateDoc() error = %v, want it deferred to baking", err)
+	}
+	if _, err := lazy.Warmup(); err == nil || !strings.Contains(err.Error(), "unclosed <div>") {
+		t.Errorf("Warmup() error = %v, want the unclosed <div>", err)
+	}
+
+	unchecked := New("http://example.com/errors")
+	if _, err := unchecked.TemplateDoc("BrokenTemplate", "", broken); err != nil {
+		t.Errorf("TemplateDoc() without WithValidateDocs = %v", err)
+	}
+}
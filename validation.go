@@ -0,0 +1,20 @@
+package rfc7807
+
+import "net/http"
+
+// Violation describes one invalid field of a request.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Unprocessable writes a 422 problem listing violations in an "errors" extension.
+// The title defaults to the status text and can be changed with
+// WithUnprocessableTitle, e.g. to use a registered doc.
+func (rfc7807 *RFC7807) Unprocessable(w http.ResponseWriter, violations ...Violation) int {
+	if violations == nil {
+		violations = []Violation{}
+	}
+
+	return rfc7807.error(w, nil, rfc7807.unprocessableTitle, http.StatusUnprocessableEntity, "The request contains invalid fields.", []*Extension{Ext("errors", violations)})
+}
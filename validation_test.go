@@ -0,0 +1,55 @@
+package rfc7807
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestUnprocessable(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []Option
+		violations []Violation
+		title      string
+		errors     []interface{}
+	}{
+		{
+			name:       "violations",
+			violations: []Violation{{Field: "name", Message: "is required"}, {Field: "age", Message: "must be positive"}},
+			title:      "Unprocessable Entity",
+			errors: []interface{}{
+				map[string]interface{}{"field": "name", "message": "is required"},
+				map[string]interface{}{"field": "age", "message": "must be positive"},
+			},
+		},
+		{
+			name:   "no violations",
+			title:  "Unprocessable Entity",
+			errors: []interface{}{},
+		},
+		{
+			name:       "custom title",
+			options:    []Option{WithUnprocessableTitle("Validation Failed")},
+			violations: []Violation{{Field: "name", Message: "is required"}},
+			title:      "Validation Failed",
+			errors:     []interface{}{map[string]interface{}{"field": "name", "message": "is required"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", test.options...)
+			w := httptest.NewRecorder()
+			problems.Unprocessable(w, test.violations...)
+
+			problem := decode(t, w)
+			if w.Code != 422 || problem["status"] != 422.0 || problem["title"] != test.title || problem["detail"] == "" {
+				t.Errorf("problem = %d %v, want a 422 %q problem with detail", w.Code, problem, test.title)
+			}
+			if !reflect.DeepEqual(problem["errors"], test.errors) {
+				t.Errorf("errors = %v, want %v", problem["errors"], test.errors)
+			}
+		})
+	}
+}
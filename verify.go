@@ -0,0 +1,85 @@
+package rfc7807
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pressly/chi"
+)
+
+// Verify checks that the type URL of every documented problem resolves to a
+// route served by the doc mux, and returns one error per mismatch. Like chi, it
+// matches the decoded path of the type against the route patterns, so a page
+// registered under an escaped pattern such as "/Not%20Found.html" is reported.
+func (rfc7807 *RFC7807) Verify() []error {
+	rfc7807.registry.RLock()
+	defer rfc7807.registry.RUnlock()
+
+	errs := append([]error{}, rfc7807.errs...)
+
+	base, err := rfc7807.base()
+	if err != nil {
+		return append(errs, fmt.Errorf("rfc7807: invalid base URL %q: %v", rfc7807.URL, err))
+	}
+	basePath := strings.TrimSuffix(base.Path, "/")
+
+	patterns := map[string]bool{}
+	if rfc7807.mux != nil {
+		collectPatterns(patterns, "", rfc7807.mux.Routes())
+	}
+
+	titles := make([]string, 0, len(rfc7807.docs))
+	for title := range rfc7807.docs {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+
+	docs := make([]*doc, 0, len(titles)+1)
+	for _, title := range titles {
+		docs = append(docs, rfc7807.docs[title])
+	}
+	if rfc7807.fallbackDoc != nil {
+		docs = append(docs, rfc7807.fallbackDoc)
+	}
+
+	for _, doc := range docs {
+		title := doc.title
+		if doc.url == "" || doc.external {
+			continue
+		}
+
+		typeURL, err := url.Parse(doc.url)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rfc7807: invalid type %q for %q: %v", doc.url, title, err))
+			continue
+		}
+
+		typePath := typeURL.Path
+		if !strings.HasPrefix(typePath, basePath+"/") {
+			errs = append(errs, fmt.Errorf("rfc7807: type %q for %q is outside of base URL %q", doc.url, title, rfc7807.URL))
+			continue
+		}
+
+		if route := strings.TrimPrefix(typePath, basePath); !patterns[route] {
+			errs = append(errs, fmt.Errorf("rfc7807: type %q for %q does not match any route (expected %q, got %q)", doc.url, title, routePath(doc.path), route))
+		}
+	}
+
+	return errs
+}
+
+func collectPatterns(patterns map[string]bool, prefix string, routes []chi.Route) {
+	for _, route := range routes {
+		pattern := prefix + route.Pattern
+		if route.SubRoutes != nil {
+			collectPatterns(patterns, strings.TrimSuffix(pattern, "/*"), route.SubRoutes.Routes())
+			continue
+		}
+		if _, ok := route.Handlers[http.MethodGet]; ok {
+			patterns[pattern] = true
+		}
+	}
+}
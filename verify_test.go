@@ -0,0 +1,50 @@
+package rfc7807
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	if errs := problems.Verify(); len(errs) != 0 {
+		t.Errorf("Verify() = %v, want no errors", errs)
+	}
+}
+
+func TestVerifyBaseURLMismatch(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	problems.URL = "http://example.com/problems"
+
+	errs := problems.Verify()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "outside of base URL") {
+		t.Errorf("Verify() = %v, want one base URL mismatch", errs)
+	}
+}
+
+func TestVerifyEscapedPage(t *testing.T) {
+	problems := New("http://example.com/errors")
+	if _, err := problems.Doc("Not Found", "missing", problems.Profile("billing eu", []byte("billing"))); err != nil {
+		t.Fatal(err)
+	}
+
+	// The router matches the decoded path, so the page is routed unescaped.
+	if w := serve(problems, http.MethodGet, "/Not%20Found.html", nil); w.Code != http.StatusOK {
+		t.Fatalf("GET /Not%%20Found.html = %d, want 200", w.Code)
+	}
+	if w := serve(problems, http.MethodGet, "/_profiles/billing%20eu.html", nil); w.Code != http.StatusOK {
+		t.Fatalf("GET /_profiles/billing%%20eu.html = %d, want 200", w.Code)
+	}
+
+	if errs := problems.Verify(); len(errs) != 0 {
+		t.Errorf("Verify() = %v, want no errors", errs)
+	}
+}
@@ -0,0 +1,71 @@
+package rfc7807
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WarmupReport describes the doc pages baked by Warmup.
+type WarmupReport struct {
+	Docs     []DocReport
+	Bytes    int
+	Duration time.Duration
+}
+
+// DocReport describes the doc page of one registered problem. Bytes is zero for
+// problems without a page.
+type DocReport struct {
+	Title    string
+	Bytes    int
+	Duration time.Duration
+	Err      error
+}
+
+// Warmup bakes the doc page of every registered problem, which WithLazyDocs
+// otherwise defers to the first request, and reports their sizes and baking
+// times by title. The error is the first doc failure; the report still covers
+// every title.
+func (rfc7807 *RFC7807) Warmup() (WarmupReport, error) {
+	rfc7807.registry.RLock()
+	docs := make([]*doc, 0, len(rfc7807.docs))
+	for _, doc := range rfc7807.docs {
+		docs = append(docs, doc)
+	}
+	rfc7807.registry.RUnlock()
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].title < docs[j].title
+	})
+
+	report := WarmupReport{Docs: make([]DocReport, 0, len(docs))}
+	var first error
+	start := time.Now()
+	for _, doc := range docs {
+		docStart := time.Now()
+		html, err := doc.bytes()
+		if err != nil {
+			err = fmt.Errorf("rfc7807: failed to bake doc of %q: %w", doc.title, err)
+			if first == nil {
+				first = err
+			}
+		}
+
+		size := len(html)
+		if len(doc.variants) > 0 {
+			size = 0
+			for _, variant := range doc.variants {
+				size += len(variant)
+			}
+		}
+		for _, locale := range doc.locales {
+			size += len(locale.html)
+		}
+
+		report.Docs = append(report.Docs, DocReport{Title: doc.title, Bytes: size, Duration: time.Since(docStart), Err: err})
+		report.Bytes += size
+	}
+	report.Duration = time.Since(start)
+
+	return report, first
+}
@@ -0,0 +1,62 @@
+package rfc7807
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWarmup(t *testing.T) {
+	funcs := template.FuncMap{"fail": func() (string, error) { return "", errors.New("sink down") }}
+
+	problems := New("http://example.com/errors", WithLazyDocs())
+	if _, err := problems.Doc("NotFound", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.TemplateDoc("Gone", "", "<h1>{{.Title}}</h1>", Localized("ja", []byte("<h1>消えた</h1>"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problems.TemplateDocFuncs("Broken", "", "<h1>{{fail}}</h1>", funcs); err != nil {
+		t.Fatalf("lazy doc baked at registration: %v", err)
+	}
+	problems.ExternalDoc("Upstream", "https://upstream.example.com/probs/down")
+
+	report, err := problems.Warmup()
+	if err == nil || !strings.Contains(err.Error(), `"Broken"`) {
+		t.Errorf("Warmup() error = %v, want the broken doc", err)
+	}
+
+	titles := []string{}
+	total := 0
+	for _, doc := range report.Docs {
+		titles = append(titles, doc.Title)
+		total += doc.Bytes
+
+		if (doc.Err != nil) != (doc.Title == "Broken") {
+			t.Errorf("%s: Err = %v", doc.Title, doc.Err)
+		}
+	}
+	if want := []string{"Broken", "Gone", "NotFound", "Upstream"}; strings.Join(titles, ",") != strings.Join(want, ",") {
+		t.Errorf("report covers %v, want %v", titles, want)
+	}
+	if report.Bytes != total {
+		t.Errorf("report total = %d bytes, want %d", report.Bytes, total)
+	}
+
+	sizes := map[string]int{}
+	for _, doc := range report.Docs {
+		sizes[doc.Title] = doc.Bytes
+	}
+	page := serve(problems, http.MethodGet, "/NotFound.html", nil).Body.Len()
+	if sizes["NotFound"] != page {
+		t.Errorf("NotFound size = %d, want the %d bytes served", sizes["NotFound"], page)
+	}
+	if want := len("<h1>Gone</h1>") + len("<h1>消えた</h1>"); sizes["Gone"] != want {
+		t.Errorf("Gone size = %d, want %d with its translation", sizes["Gone"], want)
+	}
+	if sizes["Upstream"] != 0 {
+		t.Errorf("external doc size = %d, want 0", sizes["Upstream"])
+	}
+}
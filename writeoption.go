@@ -0,0 +1,49 @@
+package rfc7807
+
+import "net/http"
+
+// WriteOption overrides how a single ErrorOpts call writes its problem.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	indent     *string
+	mediaType  string
+	extensions []*Extension
+}
+
+// Compact writes the problem without indentation.
+func Compact() WriteOption {
+	return Indent("")
+}
+
+// Indent indents the problem with indent.
+func Indent(indent string) WriteOption {
+	return func(options *writeOptions) {
+		options.indent = &indent
+	}
+}
+
+// MediaType writes the problem as JSON with mediaType as Content-Type, e.g.
+// "application/json" for a legacy client, skipping content negotiation.
+func MediaType(mediaType string) WriteOption {
+	return func(options *writeOptions) {
+		options.mediaType = mediaType
+	}
+}
+
+// Extensions adds extensions to the problem.
+func Extensions(extensions ...*Extension) WriteOption {
+	return func(options *writeOptions) {
+		options.extensions = append(options.extensions, extensions...)
+	}
+}
+
+// ErrorOpts is like Error, with options applying to this call only.
+func (rfc7807 *RFC7807) ErrorOpts(w http.ResponseWriter, title string, status int, detail string, opts ...WriteOption) int {
+	options := &writeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return rfc7807.write(w, nil, title, status, detail, options.extensions, options)
+}
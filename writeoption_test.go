@@ -0,0 +1,54 @@
+package rfc7807
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorOpts(t *testing.T) {
+	const (
+		indented = "{\n  \"title\": \"Not Found\",\n  \"status\": 404,\n  \"detail\": \"\"\n}\n"
+		compact  = `{"title":"Not Found","status":404,"detail":""}` + "\n"
+		jsonType = "application/problem+json; charset=utf-8"
+	)
+
+	tests := []struct {
+		name        string
+		options     []Option
+		opts        []WriteOption
+		body        string
+		contentType string
+	}{
+		{"no override", nil, nil, indented, jsonType},
+		{"Compact", nil, []WriteOption{Compact()}, compact, jsonType},
+		{"Indent", nil, []WriteOption{Indent("\t")}, "{\n\t\"title\": \"Not Found\",\n\t\"status\": 404,\n\t\"detail\": \"\"\n}\n", jsonType},
+		{"Indent over the pretty default", []Option{WithPrettyQueryParam("pretty")}, []WriteOption{Indent("  ")}, indented, jsonType},
+		{"last wins", nil, []WriteOption{Indent("\t"), Compact()}, compact, jsonType},
+		{"MediaType", nil, []WriteOption{MediaType("application/json"), Compact()}, compact, "application/json"},
+		{"MediaType over JSON:API", []Option{WithErrorFormat(FormatJSONAPI)}, []WriteOption{MediaType("application/json"), Compact()}, compact, "application/json"},
+		{"Extensions", nil, []WriteOption{Compact(), Extensions(Ext("a", 1)), Extensions(Ext("b", 2))}, `{"title":"Not Found","status":404,"detail":"","a":1,"b":2}` + "\n", jsonType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := New("http://example.com/errors", test.options...)
+
+			w := httptest.NewRecorder()
+			if got := problems.ErrorOpts(w, "", 404, "", test.opts...); got != 404 {
+				t.Errorf("ErrorOpts() = %d, want 404", got)
+			}
+			if w.Body.String() != test.body || w.Header().Get("Content-Type") != test.contentType {
+				t.Errorf("got %q %q\nwant %q %q", w.Header().Get("Content-Type"), w.Body.String(), test.contentType, test.body)
+			}
+
+			// The overrides apply to that call only.
+			w = httptest.NewRecorder()
+			problems.ErrorOpts(w, "", 404, "")
+			plain := httptest.NewRecorder()
+			problems.Error(plain, "", 404, "")
+			if w.Body.String() != plain.Body.String() || w.Header().Get("Content-Type") != plain.Header().Get("Content-Type") {
+				t.Errorf("later call got %q, want %q", w.Body.String(), plain.Body.String())
+			}
+		})
+	}
+}